@@ -0,0 +1,210 @@
+package log
+
+import (
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+)
+
+// DropPolicy decides what AsyncWriter does when its queue is full
+type DropPolicy int
+
+const (
+	// Block blocks Write until there is room in the queue
+	Block DropPolicy = iota
+	// DropOldest discards the oldest queued entry to make room for the new one
+	DropOldest
+	// DropNewest discards the entry being written
+	DropNewest
+)
+
+var ErrWriterClosed = errors.New("writer closed")
+
+// AsyncWriter writes to the underlying writer in a background goroutine, so a slow disk or network writer
+// doesn't stall goroutines which are logging. Entries are queued in a bounded ring buffer.
+// Example:
+// aw := log.NewAsyncWriter(fw, 1024, log.DropOldest)
+// log.SetDefault(log.NewLogger(aw))
+// defer aw.Close()
+type AsyncWriter struct {
+	dropped uint64 // accessed atomically, keep it 64-bit aligned
+
+	w      io.Writer
+	policy DropPolicy
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	queue   [][]byte // ring buffer
+	head    int
+	size    int
+	free    [][]byte // buffers for reuse
+	writing bool
+	closed  bool
+	err     error
+	done    chan struct{}
+}
+
+var _ io.WriteCloser = (*AsyncWriter)(nil)
+
+// NewAsyncWriter creates an AsyncWriter queueing at most size entries
+func NewAsyncWriter(w io.Writer, size int, policy DropPolicy) *AsyncWriter {
+	if size < 1 {
+		size = 1
+	}
+	aw := &AsyncWriter{
+		w:      w,
+		policy: policy,
+		queue:  make([][]byte, size),
+		done:   make(chan struct{}),
+	}
+	aw.cond = sync.NewCond(&aw.mu)
+	go aw.run()
+	return aw
+}
+
+// Write queues a copy of p. It never returns an error of the underlying writer, see Flush
+func (w *AsyncWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return 0, ErrWriterClosed
+	}
+
+	if w.size == len(w.queue) {
+		switch w.policy {
+		case DropNewest:
+			atomic.AddUint64(&w.dropped, 1)
+			return len(p), nil
+		case DropOldest:
+			w.free = append(w.free, w.pop())
+			atomic.AddUint64(&w.dropped, 1)
+		default:
+			for w.size == len(w.queue) && !w.closed {
+				w.cond.Wait()
+			}
+			if w.closed {
+				return 0, ErrWriterClosed
+			}
+		}
+	}
+
+	var b []byte
+	if n := len(w.free); n > 0 {
+		b = w.free[n-1]
+		w.free = w.free[:n-1]
+	}
+	w.queue[(w.head+w.size)%len(w.queue)] = append(b[:0], p...)
+	w.size++
+	w.cond.Broadcast()
+	return len(p), nil
+}
+
+// Dropped returns the number of entries discarded because the queue was full
+func (w *AsyncWriter) Dropped() uint64 {
+	return atomic.LoadUint64(&w.dropped)
+}
+
+// Flush waits until all queued entries are written, then flushes the underlying writer if it implements
+// Flush() error or Sync() error. It returns the first error met since the last Flush.
+func (w *AsyncWriter) Flush() error {
+	w.mu.Lock()
+	for w.size > 0 || w.writing {
+		w.cond.Wait()
+	}
+	err := w.err
+	w.err = nil
+	w.mu.Unlock()
+
+	if fErr := flushWriter(w.w); err == nil {
+		err = fErr
+	}
+	return err
+}
+
+// Close writes all queued entries and closes the underlying writer if it implements io.Closer, except
+// os.Stdout and os.Stderr which are only flushed. Write returns ErrWriterClosed after Close.
+func (w *AsyncWriter) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return ErrWriterClosed
+	}
+	w.closed = true
+	w.cond.Broadcast()
+	w.mu.Unlock()
+
+	<-w.done
+	err := w.err
+	if fErr := flushWriter(w.w); err == nil {
+		err = fErr
+	}
+	if c, ok := w.w.(io.Closer); ok && !isStdStream(w.w) {
+		if cErr := c.Close(); err == nil {
+			err = cErr
+		}
+	}
+	return err
+}
+
+func (w *AsyncWriter) run() {
+	defer close(w.done)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for {
+		for w.size == 0 && !w.closed {
+			w.cond.Wait()
+		}
+		if w.size == 0 {
+			return
+		}
+
+		b := w.pop()
+		w.writing = true
+		w.cond.Broadcast()
+		w.mu.Unlock()
+
+		_, err := w.w.Write(b)
+
+		w.mu.Lock()
+		w.writing = false
+		if err != nil && w.err == nil {
+			w.err = err
+		}
+		w.free = append(w.free, b)
+		w.cond.Broadcast()
+	}
+}
+
+// pop removes the head of the queue. w.mu must be held
+func (w *AsyncWriter) pop() []byte {
+	b := w.queue[w.head]
+	w.queue[w.head] = nil
+	w.head = (w.head + 1) % len(w.queue)
+	w.size--
+	return b
+}
+
+// isStdStream reports whether w is os.Stdout or os.Stderr, which are shared by the process and never closed
+func isStdStream(w io.Writer) bool {
+	return w == os.Stdout || w == os.Stderr
+}
+
+// flushWriter flushes w if it implements Flush() error or Sync() error.
+// Files other than regular files, e.g. terminals and pipes, are not synced as they may not support it.
+func flushWriter(w io.Writer) error {
+	switch v := w.(type) {
+	case *os.File:
+		if fi, err := v.Stat(); err != nil || !fi.Mode().IsRegular() {
+			return nil
+		}
+		return v.Sync()
+	case interface{ Flush() error }:
+		return v.Flush()
+	case interface{ Sync() error }:
+		return v.Sync()
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,66 @@
+package log_test
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gopub/log"
+)
+
+type blockingWriter struct {
+	mu      sync.Mutex
+	buf     bytes.Buffer
+	release chan struct{}
+}
+
+func (w *blockingWriter) Write(p []byte) (int, error) {
+	<-w.release
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(p)
+}
+
+func TestAsyncWriter(t *testing.T) {
+	policies := []log.DropPolicy{log.DropNewest, log.DropOldest}
+	for _, p := range policies {
+		w := &blockingWriter{release: make(chan struct{})}
+		aw := log.NewAsyncWriter(w, 2, p)
+		// the first one may be taken by the background goroutine, then at most 2 are queued
+		for _, s := range []string{"1\n", "2\n", "3\n", "4\n", "5\n"} {
+			if _, err := aw.Write([]byte(s)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		close(w.release)
+		if err := aw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		n := strings.Count(w.buf.String(), "\n")
+		if uint64(n)+aw.Dropped() != 5 || n < 2 || n > 3 {
+			t.Errorf("policy %d: written %d, dropped %d", p, n, aw.Dropped())
+		}
+		if p == log.DropOldest && !strings.HasSuffix(w.buf.String(), "4\n5\n") {
+			t.Errorf("expected the latest entries kept, got %q", w.buf.String())
+		}
+		if _, err := aw.Write([]byte("6\n")); err != log.ErrWriterClosed {
+			t.Errorf("expected ErrWriterClosed, got %v", err)
+		}
+	}
+}
+
+func TestAsyncWriter_Flush(t *testing.T) {
+	var buf bytes.Buffer
+	aw := log.NewAsyncWriter(&buf, 16, log.Block)
+	l := log.NewLogger(aw)
+	for i := 0; i < 100; i++ {
+		l.Info(i)
+	}
+	if err := aw.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(buf.String(), "\n"); n != 100 {
+		t.Errorf("expected 100 lines, got %d", n)
+	}
+}
@@ -0,0 +1,88 @@
+package log
+
+import (
+	"io"
+	"os"
+)
+
+const (
+	colorReset = "\x1b[0m"
+	colorName  = "\x1b[34m"
+	colorKey   = "\x1b[36m"
+)
+
+func levelColor(l Level) string {
+	switch l {
+	case TraceLevel:
+		return "\x1b[90m"
+	case DebugLevel:
+		return "\x1b[35m"
+	case InfoLevel:
+		return "\x1b[32m"
+	case WarnLevel:
+		return "\x1b[33m"
+	case ErrorLevel:
+		return "\x1b[31m"
+	case FatalLevel, PanicLevel:
+		return "\x1b[1;31m"
+	default:
+		return colorReset
+	}
+}
+
+type ColorMode int
+
+const (
+	// ColorAuto colors output only if it's a terminal and environment variable NO_COLOR is not set or empty
+	ColorAuto ColorMode = iota
+	ColorAlways
+	ColorNever
+)
+
+// ColorEncoder encodes an entry in the plain-text layout, with level tag, logger name and field keys colored
+// by ANSI escapes.
+// Example:
+// log.Default().SetEncoder(log.NewColorEncoder(log.ColorAuto))
+type ColorEncoder struct {
+	mode ColorMode
+}
+
+var _ Encoder = (*ColorEncoder)(nil)
+
+func NewColorEncoder(mode ColorMode) *ColorEncoder {
+	return &ColorEncoder{mode: mode}
+}
+
+func (c *ColorEncoder) Encode(buf []byte, e *Entry) []byte {
+	renderEntry(&buf, e, c.mode != ColorNever)
+	return buf
+}
+
+func (c *ColorEncoder) forOutput(colorable bool) Encoder {
+	if c.mode == ColorAlways || (c.mode == ColorAuto && colorable) {
+		return c
+	}
+	return TextFormatter{}
+}
+
+// outputEncoder is implemented by encoders which encode differently depending on the output
+type outputEncoder interface {
+	forOutput(colorable bool) Encoder
+}
+
+// isColorable reports whether w is a terminal which accepts colored output
+func isColorable(w io.Writer) bool {
+	// see https://no-color.org, an empty NO_COLOR doesn't disable color
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return fi.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,54 @@
+package log
+
+import (
+	"bytes"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestColorEncoder_forOutputShared(t *testing.T) {
+	var plain, auto bytes.Buffer
+	r := newRender()
+	r.AddOutput(&plain, AllLevel, TextFormatter{})
+	r.AddOutput(&auto, AllLevel, NewColorEncoder(ColorAuto))
+	e := newEntryAt(Lname, InfoLevel, "db", nil, "hello", time.Time{})
+	if err := r.Render(e); err != nil {
+		t.Fatal(err)
+	}
+	if len(r.encoded) != 1 {
+		t.Errorf("expected 1 encoding, got %d", len(r.encoded))
+	}
+	if plain.String() != auto.String() || plain.String() != "[INF] [db] hello\n" {
+		t.Errorf("got %q and %q", plain.String(), auto.String())
+	}
+}
+
+func TestIsColorable_NO_COLOR(t *testing.T) {
+	f, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Skip(err)
+	}
+	defer f.Close()
+	old, ok := os.LookupEnv("NO_COLOR")
+	defer func() {
+		if ok {
+			os.Setenv("NO_COLOR", old)
+		} else {
+			os.Unsetenv("NO_COLOR")
+		}
+	}()
+
+	os.Unsetenv("NO_COLOR")
+	if !isColorable(f) {
+		t.Skipf("%s is not a character device", os.DevNull)
+	}
+	os.Setenv("NO_COLOR", "")
+	if !isColorable(f) {
+		t.Error("empty NO_COLOR must not disable color")
+	}
+	os.Setenv("NO_COLOR", "1")
+	if isColorable(f) {
+		t.Error("NO_COLOR=1 must disable color")
+	}
+}
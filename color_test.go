@@ -0,0 +1,45 @@
+package log_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/gopub/log"
+)
+
+func TestColorEncoder(t *testing.T) {
+	newLogger := func(mode log.ColorMode) (*log.Logger, *bytes.Buffer) {
+		var buf bytes.Buffer
+		l := log.NewLogger(&buf)
+		l.SetName("db")
+		l.SetFlags(log.Lname)
+		l.SetEncoder(log.NewColorEncoder(mode))
+		return l, &buf
+	}
+
+	t.Run("Always", func(t *testing.T) {
+		l, buf := newLogger(log.ColorAlways)
+		l.Warnw("slow", "ms", 12)
+		expected := "\x1b[33m[WRN]\x1b[0m \x1b[34m[db]\x1b[0m \x1b[36mms\x1b[0m:12  | slow\n"
+		if buf.String() != expected {
+			t.Errorf("expected %q, got %q", expected, buf.String())
+		}
+	})
+
+	plain := "[WRN] [db] ms:12  | slow\n"
+	t.Run("Never", func(t *testing.T) {
+		l, buf := newLogger(log.ColorNever)
+		l.Warnw("slow", "ms", 12)
+		if buf.String() != plain {
+			t.Errorf("expected %q, got %q", plain, buf.String())
+		}
+	})
+
+	t.Run("AutoNonFile", func(t *testing.T) {
+		l, buf := newLogger(log.ColorAuto)
+		l.Warnw("slow", "ms", 12)
+		if buf.String() != plain {
+			t.Errorf("expected %q, got %q", plain, buf.String())
+		}
+	})
+}
@@ -0,0 +1,225 @@
+package log
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Config is the setup loaded from environment variables or a config file, empty values are ignored.
+type Config struct {
+	// Level is the package-level level, e.g. info. Env LOG_LEVEL
+	Level string `json:"level"`
+	// Flags are flag names separated by | or ',', e.g. date|millisecond|shortfile, or a number. Env LOG_FLAGS
+	Flags string `json:"flags"`
+	// Format of the default logger: text, json, logfmt or color. Env LOG_FORMAT
+	Format string `json:"format"`
+	// Levels overrides levels by logger name, e.g. db=debug,http=warn. Env LOG_LEVELS
+	Levels string `json:"levels"`
+}
+
+var flagNames = map[string]int{
+	"date":         Ldate,
+	"time":         Ltime,
+	"millisecond":  Lmillisecond,
+	"milliseconds": Lmillisecond,
+	"microsecond":  Lmicroseconds,
+	"microseconds": Lmicroseconds,
+	"longfile":     Llongfile,
+	"shortfile":    Lshortfile,
+	"utc":          LUTC,
+	"function":     Lfunction,
+	"name":         Lname,
+	"std":          LstdFlags,
+}
+
+// ParseFlags parses flag names separated by | or ',' case-insensitively, e.g. date|millisecond|shortfile,
+// or a decimal number
+func ParseFlags(s string) (int, error) {
+	s = strings.TrimSpace(s)
+	if n, err := strconv.Atoi(s); err == nil {
+		return n, nil
+	}
+	flags := 0
+	for _, name := range strings.FieldsFunc(s, func(r rune) bool { return r == '|' || r == ',' }) {
+		f, ok := flagNames[strings.ToLower(strings.TrimSpace(name))]
+		if !ok {
+			return 0, fmt.Errorf("unknown flag %q", name)
+		}
+		flags |= f
+	}
+	if flags == 0 {
+		return 0, fmt.Errorf("no flags in %q", s)
+	}
+	return flags, nil
+}
+
+// encoderByFormat returns the encoder of format. nil is returned for text
+func encoderByFormat(format string) (Encoder, error) {
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case "text":
+		return nil, nil
+	case "json":
+		return NewJSONEncoder(), nil
+	case "logfmt":
+		return NewLogfmtEncoder(), nil
+	case "color":
+		return NewColorEncoder(ColorAuto), nil
+	default:
+		return nil, fmt.Errorf("unknown format %q", format)
+	}
+}
+
+// Apply validates c and applies it to the package-level settings and the default logger.
+// Nothing is applied if c is invalid.
+func (c *Config) Apply() error {
+	var level Level
+	var flags int
+	var enc Encoder
+	var err error
+	if c.Level != "" {
+		if level, err = ParseLevel(c.Level); err != nil {
+			return fmt.Errorf("level: %w", err)
+		}
+	}
+	if c.Flags != "" {
+		if flags, err = ParseFlags(c.Flags); err != nil {
+			return fmt.Errorf("flags: %w", err)
+		}
+	}
+	if c.Format != "" {
+		if enc, err = encoderByFormat(c.Format); err != nil {
+			return fmt.Errorf("format: %w", err)
+		}
+	}
+	if c.Levels != "" {
+		if _, err = parseLevels(c.Levels); err != nil {
+			return fmt.Errorf("levels: %w", err)
+		}
+	}
+
+	if c.Level != "" {
+		SetLevel(level)
+	}
+	if c.Flags != "" {
+		SetFlags(flags)
+	}
+	if c.Format != "" {
+		Default().SetEncoder(enc)
+	}
+	if c.Levels != "" {
+		// * in levels takes precedence over level
+		_ = SetLevels(c.Levels)
+	}
+	return nil
+}
+
+// ConfigFromEnv loads config from environment variables LOG_LEVEL, LOG_FLAGS, LOG_FORMAT and LOG_LEVELS
+func ConfigFromEnv() *Config {
+	return &Config{
+		Level:  os.Getenv("LOG_LEVEL"),
+		Flags:  os.Getenv("LOG_FLAGS"),
+		Format: os.Getenv("LOG_FORMAT"),
+		Levels: os.Getenv("LOG_LEVELS"),
+	}
+}
+
+// Configure loads config from r and applies it. The content is either a JSON object, or YAML-like lines of
+// key: value, e.g.
+// level: info
+// flags: date|millisecond|shortfile
+// format: json
+// levels: db=debug,http=warn
+// In JSON, levels can also be an object, e.g. {"levels": {"db": "debug"}}
+func Configure(r io.Reader) error {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("read: %w", err)
+	}
+	data = bytes.TrimSpace(data)
+	var c *Config
+	if len(data) > 0 && data[0] == '{' {
+		c, err = parseJSONConfig(data)
+	} else {
+		c, err = parseLineConfig(data)
+	}
+	if err != nil {
+		return err
+	}
+	return c.Apply()
+}
+
+func parseJSONConfig(data []byte) (*Config, error) {
+	var raw struct {
+		Config
+		Levels json.RawMessage `json:"levels"`
+	}
+	// unknown keys are rejected like unknown lines of the line format
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&raw); err != nil {
+		return nil, fmt.Errorf("unmarshal: %w", err)
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("unmarshal: unexpected data after config")
+	}
+	c := raw.Config
+	if len(raw.Levels) == 0 || string(raw.Levels) == "null" {
+		return &c, nil
+	}
+	if err := json.Unmarshal(raw.Levels, &c.Levels); err == nil {
+		return &c, nil
+	}
+	var m map[string]string
+	if err := json.Unmarshal(raw.Levels, &m); err != nil {
+		return nil, fmt.Errorf("levels should be a string or an object: %w", err)
+	}
+	items := make([]string, 0, len(m))
+	for k, v := range m {
+		items = append(items, k+"="+v)
+	}
+	sort.Strings(items)
+	c.Levels = strings.Join(items, ",")
+	return &c, nil
+}
+
+func parseLineConfig(data []byte) (*Config, error) {
+	c := &Config{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		i := strings.IndexByte(line, ':')
+		if i < 0 {
+			return nil, fmt.Errorf("line %d: missing colon", n)
+		}
+		key := strings.TrimSpace(line[:i])
+		val := strings.Trim(strings.TrimSpace(line[i+1:]), `"'`)
+		switch strings.ToLower(key) {
+		case "level":
+			c.Level = val
+		case "flags":
+			c.Flags = val
+		case "format":
+			c.Format = val
+		case "levels":
+			c.Levels = val
+		default:
+			return nil, fmt.Errorf("line %d: unknown key %q", n, key)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("scan: %w", err)
+	}
+	return c, nil
+}
@@ -0,0 +1,50 @@
+package log_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gopub/log"
+)
+
+func TestConfigure(t *testing.T) {
+	level, flags := log.GetLevel(), log.Flags()
+	defer func() {
+		log.SetLevel(level)
+		log.SetFlags(flags)
+		log.SetLevels("")
+	}()
+
+	err := log.Configure(strings.NewReader(`
+# comment
+level: warn
+flags: date|shortfile
+levels: cfg.db=debug
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if log.GetLevel() != log.WarnLevel || log.Flags() != log.Ldate|log.Lshortfile {
+		t.Errorf("unexpected level %v or flags %d", log.GetLevel(), log.Flags())
+	}
+	if log.LoggerLevels()["cfg.db"] != log.DebugLevel {
+		t.Errorf("unexpected levels %v", log.LoggerLevels())
+	}
+
+	err = log.Configure(strings.NewReader(`{"level": "error", "levels": {"cfg.http": "info"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if log.GetLevel() != log.ErrorLevel || log.LoggerLevels()["cfg.http"] != log.InfoLevel {
+		t.Errorf("unexpected level %v or levels %v", log.GetLevel(), log.LoggerLevels())
+	}
+
+	for _, s := range []string{"level: loud", "format: xml", "flags: date|hour", "colour: red", `{"level": 1}`, `{"lvl": "debug"}`, `{"level": "info"} {}`} {
+		if err := log.Configure(strings.NewReader(s)); err == nil {
+			t.Errorf("expected error for %q", s)
+		}
+	}
+	if log.GetLevel() != log.ErrorLevel {
+		t.Errorf("invalid config shouldn't be applied")
+	}
+}
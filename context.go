@@ -1,8 +1,20 @@
 package log
 
-import "context"
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
 
-const keyLogger = "_logger"
+// contextKey is unexported to avoid collisions with keys defined in other packages
+type contextKey int
+
+const (
+	loggerKey contextKey = iota
+	fieldsKey
+	spanKey
+)
 
 // Deprecated: use BuildContext
 func ContextWithLogger(ctx context.Context, l *Logger) context.Context {
@@ -10,18 +22,180 @@ func ContextWithLogger(ctx context.Context, l *Logger) context.Context {
 }
 
 func BuildContext(ctx context.Context, l *Logger) context.Context {
-	return context.WithValue(ctx, keyLogger, l)
+	return context.WithValue(ctx, loggerKey, l)
 }
 
+// FromContext returns the logger stored by BuildContext or the default logger, carrying fields added by
+// WithContextFields
 func FromContext(ctx context.Context) *Logger {
-	l, ok := ctx.Value(keyLogger).(*Logger)
-	if ok {
+	l := loggerFromContext(ctx)
+	fields := fieldsFromContext(ctx)
+	added := fields
+	if hasFieldPrefix(fields, l.ctxFields) {
+		added = fields[len(l.ctxFields):]
+	}
+	if len(added) == 0 {
+		return l
+	}
+	nl := l.WithFields(added)
+	nl.ctxFields = fields
+	return nl
+}
+
+// loggerFromContext returns the logger stored by BuildContext, or the default logger if there is none or ctx is nil
+func loggerFromContext(ctx context.Context) *Logger {
+	if ctx == nil {
+		return Default()
+	}
+	if l, ok := ctx.Value(loggerKey).(*Logger); ok {
 		return l
 	}
-	return defaultLogger
+	return Default()
+}
+
+// WithContextFields returns a copy of ctx carrying fields made of keyValues in addition to fields added to ctx
+// before, e.g. by outer middleware layers. keyValues are pairs of (string, interface{}) or *Field
+// Example:
+// ctx = log.WithContextFields(ctx, "request_id", id)
+// ...
+// ctx = log.WithContextFields(ctx, "user_id", uid)
+// log.FromContext(ctx).Info("Signed in") // with request_id and user_id
+func WithContextFields(ctx context.Context, keyValues ...interface{}) context.Context {
+	old := fieldsFromContext(ctx)
+	added := makeFields(keyValues...)
+	fields := make([]*Field, 0, len(old)+len(added))
+	fields = append(fields, old...)
+	fields = append(fields, added...)
+	return context.WithValue(ctx, fieldsKey, fields)
+}
+
+// hasFieldPrefix reports whether fields start with the same fields as prefix
+func hasFieldPrefix(fields, prefix []*Field) bool {
+	if len(prefix) == 0 || len(fields) < len(prefix) {
+		return false
+	}
+	for i, f := range prefix {
+		if fields[i] != f {
+			return false
+		}
+	}
+	return true
+}
+
+func fieldsFromContext(ctx context.Context) []*Field {
+	if ctx == nil {
+		return nil
+	}
+	fields, _ := ctx.Value(fieldsKey).([]*Field)
+	return fields
 }
 
 // Deprecated: use FromContext
 func ContextLogger(ctx context.Context) *Logger {
 	return FromContext(ctx)
 }
+
+// ContextExtractor returns fields carried by ctx, e.g. request ID, trace ID or user ID
+type ContextExtractor func(ctx context.Context) []*Field
+
+var contextExtractors struct {
+	mu   sync.Mutex
+	list atomic.Value // []ContextExtractor, replaced on write
+}
+
+// RegisterContextExtractor registers e, whose fields are appended to entries logged by *Context methods.
+// Example:
+// log.RegisterContextExtractor(func(ctx context.Context) []*log.Field { return requestFields(ctx) })
+func RegisterContextExtractor(e ContextExtractor) {
+	contextExtractors.mu.Lock()
+	old, _ := contextExtractors.list.Load().([]ContextExtractor)
+	list := make([]ContextExtractor, len(old), len(old)+1)
+	copy(list, old)
+	contextExtractors.list.Store(append(list, e))
+	contextExtractors.mu.Unlock()
+}
+
+// contextFields returns fields added to ctx by WithContextFields except those l already carries,
+// followed by fields extracted from ctx by registered extractors
+func (l *Logger) contextFields(ctx context.Context) []*Field {
+	if ctx == nil {
+		return nil
+	}
+	fields := fieldsFromContext(ctx)
+	if hasFieldPrefix(fields, l.ctxFields) {
+		// l carries fields of an outer context, append only fields added by inner layers
+		fields = fields[len(l.ctxFields):]
+	}
+	list, _ := contextExtractors.list.Load().([]ContextExtractor)
+	if len(list) > 0 {
+		// full slice expression prevents appending into the array stored in ctx
+		fields = fields[:len(fields):len(fields)]
+	}
+	for _, e := range list {
+		fields = append(fields, e(ctx)...)
+	}
+	return fields
+}
+
+// LogContext logs args with fields of ctx, which are added by WithContextFields or extracted by registered
+// extractors
+func (l *Logger) LogContext(ctx context.Context, level Level, callDepth int, args []interface{}) {
+	if l.Level() > level || !l.allow(callDepth+1) {
+		return
+	}
+	msg := fmt.Sprintln(args...)
+	msg = msg[0 : len(msg)-1]
+	l.logFields(level, callDepth+1, msg, l.contextFields(ctx))
+}
+
+func (l *Logger) TraceContext(ctx context.Context, args ...interface{}) {
+	l.LogContext(ctx, TraceLevel, 2, args)
+}
+
+func (l *Logger) DebugContext(ctx context.Context, args ...interface{}) {
+	l.LogContext(ctx, DebugLevel, 2, args)
+}
+
+func (l *Logger) InfoContext(ctx context.Context, args ...interface{}) {
+	l.LogContext(ctx, InfoLevel, 2, args)
+}
+
+func (l *Logger) WarnContext(ctx context.Context, args ...interface{}) {
+	l.LogContext(ctx, WarnLevel, 2, args)
+}
+
+func (l *Logger) ErrorContext(ctx context.Context, args ...interface{}) {
+	l.LogContext(ctx, ErrorLevel, 2, args)
+}
+
+func (l *Logger) FatalContext(ctx context.Context, args ...interface{}) {
+	l.LogContext(ctx, FatalLevel, 2, args)
+	l.exit(1)
+}
+
+// TraceContext logs with the logger stored in ctx or the default logger, and fields of ctx
+func TraceContext(ctx context.Context, args ...interface{}) {
+	loggerFromContext(ctx).LogContext(ctx, TraceLevel, 2, args)
+}
+
+func DebugContext(ctx context.Context, args ...interface{}) {
+	loggerFromContext(ctx).LogContext(ctx, DebugLevel, 2, args)
+}
+
+func InfoContext(ctx context.Context, args ...interface{}) {
+	loggerFromContext(ctx).LogContext(ctx, InfoLevel, 2, args)
+}
+
+func WarnContext(ctx context.Context, args ...interface{}) {
+	loggerFromContext(ctx).LogContext(ctx, WarnLevel, 2, args)
+}
+
+func ErrorContext(ctx context.Context, args ...interface{}) {
+	loggerFromContext(ctx).LogContext(ctx, ErrorLevel, 2, args)
+}
+
+func FatalContext(ctx context.Context, args ...interface{}) {
+	l := loggerFromContext(ctx)
+	l.LogContext(ctx, FatalLevel, 2, args)
+	l.exit(1)
+}
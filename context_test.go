@@ -0,0 +1,103 @@
+package log_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"testing"
+
+	"github.com/gopub/log"
+)
+
+type requestIDKey struct{}
+
+// TestMain registers extractors once, as they can't be unregistered and would repeat fields if registered per test
+func TestMain(m *testing.M) {
+	log.RegisterContextExtractor(func(ctx context.Context) []*log.Field {
+		if id, ok := ctx.Value(requestIDKey{}).(string); ok {
+			return []*log.Field{log.String("request_id", id)}
+		}
+		return nil
+	})
+	os.Exit(m.Run())
+}
+
+func TestLogger_InfoContext(t *testing.T) {
+	var buf bytes.Buffer
+	l := log.NewLogger(&buf)
+	l.SetEncoder(log.NewLogfmtEncoder())
+	l.SetFlags(log.Lname)
+	ctx := context.WithValue(context.Background(), requestIDKey{}, "r1")
+	l.With("service", "api").InfoContext(ctx, "handled")
+	log.InfoContext(log.BuildContext(ctx, l), "from context")
+
+	expected := "level=info msg=handled service=api request_id=r1\nlevel=info msg=\"from context\" request_id=r1\n"
+	if buf.String() != expected {
+		t.Errorf("expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestWithContextFields(t *testing.T) {
+	var buf bytes.Buffer
+	l := log.NewLogger(&buf)
+	l.SetEncoder(log.NewLogfmtEncoder())
+	l.SetFlags(log.Lname)
+	ctx := log.BuildContext(context.Background(), l)
+	ctx = context.WithValue(ctx, "_logger", "collision")
+	outer := log.WithContextFields(ctx, "user_id", 1)
+	inner := log.WithContextFields(outer, "op", "get")
+	log.WithContextFields(outer, "op", "put") // must not affect inner
+
+	log.FromContext(inner).Info("a")
+	log.FromContext(inner).InfoContext(inner, "b")
+	l.InfoContext(inner, "c")
+	log.FromContext(outer).Info("d")
+
+	expected := "level=info msg=a user_id=1 op=get\n" +
+		"level=info msg=b user_id=1 op=get\n" +
+		"level=info msg=c user_id=1 op=get\n" +
+		"level=info msg=d user_id=1\n"
+	if buf.String() != expected {
+		t.Errorf("expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestWithContextFields_Layered(t *testing.T) {
+	var buf bytes.Buffer
+	l := log.NewLogger(&buf)
+	l.SetEncoder(log.NewLogfmtEncoder())
+	l.SetFlags(log.Lname)
+	outer := log.WithContextFields(log.BuildContext(context.Background(), l), "request_id", "r1")
+	// a middle layer takes the logger, an inner layer adds fields
+	lo := log.FromContext(outer)
+	inner := log.WithContextFields(outer, "user_id", 7)
+
+	lo.InfoContext(inner, "a")
+	log.InfoContext(log.BuildContext(inner, lo), "b")
+	log.FromContext(log.BuildContext(inner, lo)).Info("c")
+	lo.InfoContext(context.Background(), "d")
+
+	expected := "level=info msg=a request_id=r1 user_id=7\n" +
+		"level=info msg=b request_id=r1 user_id=7\n" +
+		"level=info msg=c request_id=r1 user_id=7\n" +
+		"level=info msg=d request_id=r1\n"
+	if buf.String() != expected {
+		t.Errorf("expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestInfoContext_Nil(t *testing.T) {
+	var buf bytes.Buffer
+	old := log.Default()
+	defer log.SetDefault(old)
+	l := log.NewLogger(&buf)
+	l.SetFlags(log.Lname)
+	log.SetDefault(l)
+
+	var ctx context.Context
+	log.InfoContext(ctx, "nil")
+	log.FromContext(ctx).Info("from nil")
+	if buf.String() != "[INF] nil\n[INF] from nil\n" {
+		t.Errorf("got %q", buf.String())
+	}
+}
@@ -0,0 +1,60 @@
+package log
+
+// Encoder encodes an entry into bytes. Encode appends the encoded entry (including the trailing newline) to buf
+// and returns the extended buffer. Encoders are compared with == to share encoded bytes among outputs,
+// so implementations must be comparable, e.g. pointer types.
+type Encoder interface {
+	Encode(buf []byte, e *Entry) []byte
+}
+
+// Formatter formats an entry into a text line. Format appends the line to buf and returns the extended buffer,
+// a trailing newline is added if missing.
+// Example:
+// type briefFormatter struct{}
+// func (f *briefFormatter) Format(buf []byte, e *log.Entry) []byte { return append(buf, e.Message...) }
+// log.Default().SetFormatter(&briefFormatter{})
+type Formatter interface {
+	Format(buf []byte, e *Entry) []byte
+}
+
+// TextFormatter is the default formatter which produces the bracketed plain-text line, e.g.
+// 2021-10-25 12:00:00.000+0800 [INF] [db] l/logger.go(Query):12 | id:1  | message
+type TextFormatter struct{}
+
+var _ Formatter = TextFormatter{}
+var _ Encoder = TextFormatter{}
+
+func (TextFormatter) Format(buf []byte, e *Entry) []byte {
+	renderEntry(&buf, e, false)
+	return buf
+}
+
+func (t TextFormatter) Encode(buf []byte, e *Entry) []byte {
+	return t.Format(buf, e)
+}
+
+// formatterEncoder adapts a Formatter to Encoder
+type formatterEncoder struct {
+	f Formatter
+}
+
+func (fe *formatterEncoder) Encode(buf []byte, e *Entry) []byte {
+	n := len(buf)
+	buf = fe.f.Format(buf, e)
+	if len(buf) == n || buf[len(buf)-1] != '\n' {
+		buf = append(buf, '\n')
+	}
+	return buf
+}
+
+// encoderOf returns f itself if it's an Encoder, or an adapter
+func encoderOf(f Formatter) Encoder {
+	if f == nil {
+		return nil
+	}
+	if enc, ok := f.(Encoder); ok {
+		return enc
+	}
+	// a pointer is comparable whatever f is, so outputs sharing it share encoded bytes
+	return &formatterEncoder{f: f}
+}
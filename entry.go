@@ -6,7 +6,11 @@ import (
 	"time"
 )
 
-type entry struct {
+// Entry is a log entry passed to hooks and encoders, which must treat it as read-only.
+// Name, Level, Fields, Message and Flags are always set. Time is set only if flags contain any of Ldate, Ltime,
+// Lmillisecond and Lmicroseconds. File, Line and Function are set according to Llongfile, Lshortfile and Lfunction.
+// Values of Fields are available by Field.Interface whichever constructor created them.
+type Entry struct {
 	Name     string
 	Level    Level
 	Time     time.Time
@@ -16,52 +20,80 @@ type entry struct {
 	Fields   []*Field
 	Message  string
 	Flags    int
+
+	timeEncoder TimeEncoder
 }
 
-func newEntry(flags int, level Level, name string, fields []*Field, message string, callDepth int) *entry {
-	e := &entry{}
+// AppendTime appends e.Time encoded by the logger's TimeEncoder to buf. Nothing is appended if e.Time is not set
+func (e *Entry) AppendTime(buf []byte) []byte {
+	if e.Time.IsZero() {
+		return buf
+	}
+	if e.timeEncoder == nil {
+		return DefaultTimeEncoder(buf, e.Time, e.Flags)
+	}
+	return e.timeEncoder(buf, e.Time, e.Flags)
+}
 
-	if flags&Lname != 0 {
-		e.Name = name
+// numericTime reports whether e.Time is encoded as a number, which is written without quotes in JSON
+func (e *Entry) numericTime() bool {
+	return isNumericTimeEncoder(e.timeEncoder)
+}
+
+func newEntry(flags int, level Level, name string, fields []*Field, message string, callDepth int) *Entry {
+	e := newEntryAt(flags, level, name, fields, message, time.Now())
+	if flags&(Llongfile|Lshortfile|Lfunction) != 0 {
+		pc, file, line, _ := runtime.Caller(callDepth)
+		e.setCaller(runtime.FuncForPC(pc).Name(), file, line)
 	}
+	return e
+}
+
+// newEntryAt returns an entry logged at t without caller, which is set by setCaller
+func newEntryAt(flags int, level Level, name string, fields []*Field, message string, t time.Time) *Entry {
+	e := &Entry{Name: name}
 
 	if flags&(Ltime|Ldate|Lmillisecond|Lmicroseconds) != 0 {
-		e.Time = time.Now()
+		e.Time = t
 		if flags&LUTC != 0 {
 			e.Time = e.Time.UTC()
 		}
 	}
 
-	if flags&(Llongfile|Lshortfile|Lfunction) != 0 {
-		function, file, line, _ := runtime.Caller(callDepth)
-		e.Line = line
-		if flags&(Llongfile|Lshortfile) != 0 {
-			if flags&Lshortfile != 0 {
-				file = ShortPath(file)
-			} else {
-				file = RelativePath(file)
-			}
+	e.Flags = flags
+	e.Message = message
+	e.Fields = fields
+	e.Level = level
+	return e
+}
+
+// setCaller sets File, Line and Function according to e.Flags. function is the full name, e.g. main.main
+func (e *Entry) setCaller(function, file string, line int) {
+	flags := e.Flags
+	if flags&(Llongfile|Lshortfile|Lfunction) == 0 {
+		return
+	}
+	e.Line = line
+	if flags&(Llongfile|Lshortfile) != 0 {
+		if flags&Lshortfile != 0 {
+			file = ShortPath(file)
 		} else {
-			file = ""
+			file = RelativePath(file)
 		}
+	} else {
+		file = ""
+	}
 
-		e.File = file
-		if flags&Lfunction != 0 {
-			e.Function = runtime.FuncForPC(function).Name()
-			if len(file) > 0 {
-				i := strings.LastIndex(e.Function, ".")
-				if i >= 0 {
-					e.Function = e.Function[i+1:]
-				}
+	e.File = file
+	if flags&Lfunction != 0 {
+		e.Function = function
+		if len(file) > 0 {
+			i := strings.LastIndex(e.Function, ".")
+			if i >= 0 {
+				e.Function = e.Function[i+1:]
 			}
 		}
 	}
-
-	e.Flags = flags
-	e.Message = message
-	e.Fields = fields
-	e.Level = level
-	return e
 }
 
 func RelativePath(path string) string {
@@ -0,0 +1,149 @@
+package log
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+type fieldKind uint8
+
+const (
+	anyKind fieldKind = iota
+	stringKind
+	int64Kind
+	uint64Kind
+	float64Kind
+	boolKind
+	durationKind
+	timeKind
+	errorKind
+)
+
+// Field is a key-value pair attached to entries.
+// Fields created by typed constructors like String and Int64 hold values without boxing them into interface{},
+// so creating them doesn't allocate besides the Field and encoders can write them without reflection. Value of
+// such fields is nil, use Interface to get the value of any field. Fields created with &Field{Key: k, Value: v}
+// or Any are encoded according to the dynamic type of Value. Field has unexported fields, so literals must be keyed.
+type Field struct {
+	Key   string
+	Value interface{}
+
+	kind fieldKind
+	num  int64
+	str  string
+	time time.Time
+}
+
+func String(key string, val string) *Field {
+	return &Field{Key: key, kind: stringKind, str: val}
+}
+
+func Int(key string, val int) *Field {
+	return &Field{Key: key, kind: int64Kind, num: int64(val)}
+}
+
+func Int64(key string, val int64) *Field {
+	return &Field{Key: key, kind: int64Kind, num: val}
+}
+
+func Uint64(key string, val uint64) *Field {
+	return &Field{Key: key, kind: uint64Kind, num: int64(val)}
+}
+
+func Float64(key string, val float64) *Field {
+	return &Field{Key: key, kind: float64Kind, num: int64(math.Float64bits(val))}
+}
+
+func Bool(key string, val bool) *Field {
+	f := &Field{Key: key, kind: boolKind}
+	if val {
+		f.num = 1
+	}
+	return f
+}
+
+func Duration(key string, val time.Duration) *Field {
+	return &Field{Key: key, kind: durationKind, num: int64(val)}
+}
+
+func Time(key string, val time.Time) *Field {
+	// val is kept as is, as UnixNano is undefined out of years 1678 to 2262
+	return &Field{Key: key, kind: timeKind, time: val}
+}
+
+// Err returns a field with key "error"
+func Err(err error) *Field {
+	return &Field{Key: "error", kind: errorKind, Value: err}
+}
+
+func Any(key string, val interface{}) *Field {
+	return &Field{Key: key, Value: val}
+}
+
+// Interface returns the value of f, which is boxed on each call for fields created by typed constructors
+func (f *Field) Interface() interface{} {
+	switch f.kind {
+	case stringKind:
+		return f.str
+	case int64Kind:
+		return f.num
+	case uint64Kind:
+		return uint64(f.num)
+	case float64Kind:
+		return math.Float64frombits(uint64(f.num))
+	case boolKind:
+		return f.num != 0
+	case durationKind:
+		return time.Duration(f.num)
+	case timeKind:
+		return f.time
+	default:
+		return f.Value
+	}
+}
+
+// appendText writes the value of f in plain text
+func (f *Field) appendText(buf []byte) []byte {
+	switch f.kind {
+	case stringKind:
+		return append(buf, f.str...)
+	case int64Kind:
+		return strconv.AppendInt(buf, f.num, 10)
+	case uint64Kind:
+		return strconv.AppendUint(buf, uint64(f.num), 10)
+	case float64Kind:
+		return strconv.AppendFloat(buf, math.Float64frombits(uint64(f.num)), 'g', -1, 64)
+	case boolKind:
+		return strconv.AppendBool(buf, f.num != 0)
+	case durationKind:
+		return append(buf, time.Duration(f.num).String()...)
+	case timeKind:
+		return f.time.AppendFormat(buf, time.RFC3339Nano)
+	default:
+		return append(buf, fmt.Sprintf("%+v", f.Value)...)
+	}
+}
+
+// isNilPointer reports whether v holds a nil pointer, whose methods like Error and String may panic
+func isNilPointer(v interface{}) bool {
+	rv := reflect.ValueOf(v)
+	return rv.Kind() == reflect.Ptr && rv.IsNil()
+}
+
+// methodString returns the result of f, which calls method of v like Error or String. Panics are recovered
+// like fmt does, so that a faulty value can't break rendering
+func methodString(v interface{}, method string, f func() string) (s string) {
+	defer func() {
+		if r := recover(); r != nil {
+			if isNilPointer(v) {
+				s = "<nil>"
+				return
+			}
+			s = fmt.Sprintf("!PANIC=%s method: %v", method, r)
+		}
+	}()
+	return f()
+}
@@ -0,0 +1,41 @@
+package log_test
+
+import (
+	"bytes"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gopub/log"
+)
+
+var sinkField *log.Field
+
+func TestTypedFields_Allocs(t *testing.T) {
+	n := int64(len(t.Name()) * 1000)
+	s := strconv.FormatInt(n, 10)
+	tests := map[string]func(){
+		"Int64":    func() { sinkField = log.Int64("n", n) },
+		"String":   func() { sinkField = log.String("s", s) },
+		"Duration": func() { sinkField = log.Duration("d", time.Duration(n)) },
+		"Time":     func() { sinkField = log.Time("t", time.Unix(n, 0)) },
+	}
+	for name, f := range tests {
+		if allocs := testing.AllocsPerRun(100, f); allocs > 1 {
+			t.Errorf("%s: expected at most 1 allocation, got %v", name, allocs)
+		}
+	}
+}
+
+func TestTime_OutOfNanosecondRange(t *testing.T) {
+	var buf bytes.Buffer
+	l := log.NewLogger(&buf)
+	l.SetEncoder(log.NewLogfmtEncoder())
+	l.SetFlags(log.Lname)
+	l.LogFields(log.InfoLevel, 1, "t", []*log.Field{log.Time("zero", time.Time{}),
+		log.Time("far", time.Date(3000, 1, 2, 3, 4, 5, 0, time.UTC))})
+	expected := "level=info msg=t zero=0001-01-01T00:00:00Z far=3000-01-02T03:04:05Z\n"
+	if buf.String() != expected {
+		t.Errorf("expected %q, got %q", expected, buf.String())
+	}
+}
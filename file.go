@@ -152,6 +152,16 @@ func (w *FileWriter) rotate() error {
 	return nil
 }
 
+// Sync commits the current file's content to stable storage
+func (w *FileWriter) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return errors.New("no open file")
+	}
+	return w.file.Sync()
+}
+
 func (w *FileWriter) Close() error {
 	err := w.file.Close()
 	w.file = nil
@@ -0,0 +1,24 @@
+package log
+
+// Hook is invoked for entries at its levels before they are written to outputs. Entries of Panic* methods,
+// which are not written to outputs, are fired too.
+// Example:
+// type alertHook struct{}
+// func (h *alertHook) Levels() []log.Level { return []log.Level{log.ErrorLevel, log.FatalLevel} }
+// func (h *alertHook) Fire(e *log.Entry) error { return alert.Send(e.Name, e.Message) }
+type Hook interface {
+	// Levels returns the levels of entries h is interested in
+	Levels() []Level
+	// Fire is called with an entry which must not be modified or retained after Fire returns.
+	// Fire must not log with the logger which invokes it. Values of fields are available by Field.Interface.
+	Fire(e *Entry) error
+}
+
+func hasLevel(levels []Level, level Level) bool {
+	for _, l := range levels {
+		if l == level {
+			return true
+		}
+	}
+	return false
+}
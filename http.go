@@ -0,0 +1,191 @@
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+type loggerLevel struct {
+	Name     string `json:"name"`
+	Level    string `json:"level"`
+	Override bool   `json:"override,omitempty"`
+}
+
+type levelState struct {
+	Level   string        `json:"level"`
+	Loggers []loggerLevel `json:"loggers"`
+}
+
+type levelChange struct {
+	Name  string `json:"name"`
+	Level string `json:"level"`
+	TTL   string `json:"ttl"`
+}
+
+// levelRevert restores the level of a name when its timer fires
+type levelRevert struct {
+	timer    *time.Timer
+	level    Level
+	override bool
+}
+
+// LevelHandler is an http.Handler to view and change log levels at runtime.
+// GET lists the package-level level and loggers created by GetLogger and Derive with their effective levels.
+// If several loggers share a name, the level of the one named last is listed.
+// PUT or POST changes the level of a logger name (and its descendants), or the package-level one if name is empty
+// or *. Parameters are accepted as JSON body {"name": "db", "level": "debug", "ttl": "5m"} or form values.
+// If ttl is set, the level is reverted after ttl.
+// Example:
+// http.Handle("/debug/log/level", log.NewLevelHandler())
+// curl -X PUT 'localhost:8080/debug/log/level?name=db&level=debug&ttl=5m'
+type LevelHandler struct {
+	mu      sync.Mutex
+	reverts map[string]*levelRevert
+}
+
+var _ http.Handler = (*LevelHandler)(nil)
+
+func NewLevelHandler() *LevelHandler {
+	return &LevelHandler{
+		reverts: make(map[string]*levelRevert),
+	}
+}
+
+func (h *LevelHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut, http.MethodPost:
+		if err := h.change(r); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	default:
+		w.Header().Set("Allow", "GET, PUT, POST")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(currentLevelState()); err != nil {
+		log.Printf("Encode level state: %v\n", err)
+	}
+}
+
+func (h *LevelHandler) change(r *http.Request) error {
+	var c levelChange
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		if err := json.NewDecoder(r.Body).Decode(&c); err != nil {
+			return fmt.Errorf("decode: %w", err)
+		}
+	} else {
+		if err := r.ParseForm(); err != nil {
+			return fmt.Errorf("parse form: %w", err)
+		}
+		c.Name = r.Form.Get("name")
+		c.Level = r.Form.Get("level")
+		c.TTL = r.Form.Get("ttl")
+	}
+
+	level, err := ParseLevel(c.Level)
+	if err != nil {
+		return err
+	}
+	var ttl time.Duration
+	if c.TTL != "" {
+		ttl, err = time.ParseDuration(c.TTL)
+		if err != nil {
+			return fmt.Errorf("parse ttl: %w", err)
+		}
+	}
+	if c.Name == "*" {
+		c.Name = ""
+	}
+	h.setLevel(c.Name, level, ttl)
+	return nil
+}
+
+func (h *LevelHandler) setLevel(name string, level Level, ttl time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	rev := h.reverts[name]
+	if rev != nil {
+		// keep the level before the first pending change, which is the one to revert to
+		rev.timer.Stop()
+		delete(h.reverts, name)
+	} else {
+		rev = &levelRevert{}
+		rev.level, rev.override = levelOf(name)
+	}
+
+	if name == "" {
+		SetLevel(level)
+	} else {
+		SetLoggerLevel(name, level)
+	}
+
+	if ttl <= 0 {
+		return
+	}
+	h.reverts[name] = rev
+	rev.timer = time.AfterFunc(ttl, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if h.reverts[name] != rev {
+			return
+		}
+		delete(h.reverts, name)
+		switch {
+		case name == "":
+			SetLevel(rev.level)
+		case rev.override:
+			SetLoggerLevel(name, rev.level)
+		default:
+			ResetLoggerLevel(name)
+		}
+	})
+}
+
+// levelOf returns the level of name and whether it's overridden. Empty name means the package-level level
+func levelOf(name string) (Level, bool) {
+	if name == "" {
+		return GetLevel(), false
+	}
+	level, ok := registry.Overrides()[name]
+	return level, ok
+}
+
+func currentLevelState() *levelState {
+	s := &levelState{
+		Level: GetLevel().name(),
+	}
+	overrides := registry.Overrides()
+	names := registry.Names()
+	known := make(map[string]bool, len(names))
+	for _, name := range names {
+		known[name] = true
+	}
+	// overrides may be set for names without loggers yet
+	for name := range overrides {
+		if !known[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		level, ok := registry.Lookup(name)
+		if l := registry.Logger(name); l != nil {
+			level = l.Level()
+		} else if !ok {
+			level = GetLevel()
+		}
+		_, override := overrides[name]
+		s.Loggers = append(s.Loggers, loggerLevel{Name: name, Level: level.name(), Override: override})
+	}
+	return s
+}
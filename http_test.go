@@ -0,0 +1,79 @@
+package log_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gopub/log"
+)
+
+func TestLevelHandler(t *testing.T) {
+	log.GetLogger("handler.db")
+	h := log.NewLevelHandler()
+
+	req := httptest.NewRequest(http.MethodPut, "/?name=handler&level=debug&ttl=50ms", nil)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body.String())
+	}
+	var res struct {
+		Loggers []struct {
+			Name  string
+			Level string
+		}
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, l := range res.Loggers {
+		if l.Name == "handler.db" {
+			found = l.Level == "debug"
+		}
+	}
+	if !found {
+		t.Errorf("expected handler.db at debug: %s", rec.Body.String())
+	}
+
+	time.Sleep(200 * time.Millisecond)
+	if _, ok := log.LoggerLevels()["handler"]; ok {
+		t.Error("expected level reverted after ttl")
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name":"handler","level":"loud"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected bad request, got %d", rec.Code)
+	}
+}
+
+func TestLevelHandler_OwnLevel(t *testing.T) {
+	log.GetLogger("probe.y").SetLevel(log.ErrorLevel)
+	rec := httptest.NewRecorder()
+	log.NewLevelHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	var res struct {
+		Loggers []struct {
+			Name  string
+			Level string
+		}
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	for _, l := range res.Loggers {
+		if l.Name == "probe.y" {
+			if l.Level != "error" {
+				t.Errorf("expected error, got %s", l.Level)
+			}
+			return
+		}
+	}
+	t.Errorf("probe.y not listed: %s", rec.Body.String())
+}
@@ -0,0 +1,263 @@
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+const hexDigits = "0123456789abcdef"
+
+// JSONEncoder encodes an entry as one JSON object per line, e.g.
+// {"time":"2021-10-25 12:00:00.000+0800","level":"info","logger":"db","caller":"l/logger.go:12","function":"Query","msg":"done","id":1}
+// Keys time, logger, caller and function are written only if the corresponding flags are set. Time is written as
+// a number by EpochTimeEncoder and EpochMillisTimeEncoder, otherwise as a string. Fields with these
+// keys, level, line or msg are renamed with prefix "fields.", e.g. fields.msg.
+type JSONEncoder struct{}
+
+var _ Encoder = (*JSONEncoder)(nil)
+
+func NewJSONEncoder() *JSONEncoder {
+	return &JSONEncoder{}
+}
+
+func (j *JSONEncoder) Encode(buf []byte, e *Entry) []byte {
+	buf = append(buf, '{')
+	var tb [64]byte
+	if t := e.AppendTime(tb[:0]); len(t) > 0 {
+		buf = appendJSONKey(buf, "time")
+		if e.numericTime() {
+			buf = append(buf, t...)
+		} else {
+			buf = appendJSONString(buf, string(t))
+		}
+	}
+
+	buf = appendJSONKey(buf, "level")
+	buf = appendJSONString(buf, e.Level.name())
+
+	if e.Flags&Lname != 0 && len(e.Name) > 0 {
+		buf = appendJSONKey(buf, "logger")
+		buf = appendJSONString(buf, e.Name)
+	}
+
+	if len(e.File) > 0 {
+		buf = appendJSONKey(buf, "caller")
+		buf = append(buf, '"')
+		buf = appendJSONEscaped(buf, e.File)
+		buf = append(buf, ':')
+		buf = strconv.AppendInt(buf, int64(e.Line), 10)
+		buf = append(buf, '"')
+	}
+
+	if len(e.Function) > 0 {
+		buf = appendJSONKey(buf, "function")
+		buf = appendJSONString(buf, e.Function)
+		if len(e.File) == 0 {
+			buf = appendJSONKey(buf, "line")
+			buf = strconv.AppendInt(buf, int64(e.Line), 10)
+		}
+	}
+
+	buf = appendJSONKey(buf, "msg")
+	buf = appendJSONString(buf, e.Message)
+
+	for _, f := range e.Fields {
+		buf = appendJSONKey(buf, fieldKey(f.Key))
+		buf = appendJSONField(buf, f)
+	}
+	buf = append(buf, '}', '\n')
+	return buf
+}
+
+// reservedKeys are keys of entry attributes, fields with these keys are renamed by fieldKey
+var reservedKeys = map[string]bool{
+	"time":     true,
+	"level":    true,
+	"logger":   true,
+	"caller":   true,
+	"function": true,
+	"line":     true,
+	"msg":      true,
+}
+
+// fieldKey returns key prefixed with "fields." if it collides with a key of entry attributes
+func fieldKey(key string) string {
+	if reservedKeys[key] {
+		return "fields." + key
+	}
+	return key
+}
+
+func appendJSONKey(buf []byte, key string) []byte {
+	if buf[len(buf)-1] != '{' {
+		buf = append(buf, ',')
+	}
+	buf = appendJSONString(buf, key)
+	return append(buf, ':')
+}
+
+func appendJSONField(buf []byte, f *Field) []byte {
+	switch f.kind {
+	case stringKind:
+		return appendJSONString(buf, f.str)
+	case int64Kind:
+		return strconv.AppendInt(buf, f.num, 10)
+	case uint64Kind:
+		return strconv.AppendUint(buf, uint64(f.num), 10)
+	case float64Kind:
+		return appendJSONFloat(buf, math.Float64frombits(uint64(f.num)), 64)
+	case boolKind:
+		return strconv.AppendBool(buf, f.num != 0)
+	case durationKind:
+		return appendJSONString(buf, time.Duration(f.num).String())
+	case timeKind:
+		buf = append(buf, '"')
+		buf = f.time.AppendFormat(buf, time.RFC3339Nano)
+		return append(buf, '"')
+	default:
+		return appendJSONValue(buf, f.Value)
+	}
+}
+
+func appendJSONValue(buf []byte, v interface{}) []byte {
+	switch v := v.(type) {
+	case nil:
+		return append(buf, "null"...)
+	case string:
+		return appendJSONString(buf, v)
+	case bool:
+		return strconv.AppendBool(buf, v)
+	case int:
+		return strconv.AppendInt(buf, int64(v), 10)
+	case int8:
+		return strconv.AppendInt(buf, int64(v), 10)
+	case int16:
+		return strconv.AppendInt(buf, int64(v), 10)
+	case int32:
+		return strconv.AppendInt(buf, int64(v), 10)
+	case int64:
+		return strconv.AppendInt(buf, v, 10)
+	case uint:
+		return strconv.AppendUint(buf, uint64(v), 10)
+	case uint8:
+		return strconv.AppendUint(buf, uint64(v), 10)
+	case uint16:
+		return strconv.AppendUint(buf, uint64(v), 10)
+	case uint32:
+		return strconv.AppendUint(buf, uint64(v), 10)
+	case uint64:
+		return strconv.AppendUint(buf, v, 10)
+	case float32:
+		return appendJSONFloat(buf, float64(v), 32)
+	case float64:
+		return appendJSONFloat(buf, v, 64)
+	case time.Duration:
+		return appendJSONString(buf, v.String())
+	case time.Time:
+		return appendJSONString(buf, v.Format(time.RFC3339Nano))
+	case error:
+		if isNilPointer(v) {
+			return append(buf, "null"...)
+		}
+		return appendJSONString(buf, methodString(v, "Error", func() string { return v.Error() }))
+	case json.Marshaler:
+		if isNilPointer(v) {
+			return append(buf, "null"...)
+		}
+		if b, err := marshalJSON(v); err == nil {
+			return append(buf, b...)
+		}
+		return appendJSONString(buf, fmt.Sprintf("%+v", v))
+	case fmt.Stringer:
+		if isNilPointer(v) {
+			return append(buf, "null"...)
+		}
+		return appendJSONString(buf, methodString(v, "String", func() string { return v.String() }))
+	default:
+		if b, err := json.Marshal(v); err == nil {
+			return append(buf, b...)
+		}
+		return appendJSONString(buf, fmt.Sprintf("%+v", v))
+	}
+}
+
+// marshalJSON calls m.MarshalJSON and turns its panic into an error
+func marshalJSON(m json.Marshaler) (b []byte, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("MarshalJSON: %v", r)
+		}
+	}()
+	return m.MarshalJSON()
+}
+
+// appendJSONFloat writes NaN and Inf as strings as they are not valid JSON numbers
+func appendJSONFloat(buf []byte, f float64, bitSize int) []byte {
+	switch {
+	case math.IsNaN(f):
+		return append(buf, `"NaN"`...)
+	case math.IsInf(f, 1):
+		return append(buf, `"+Inf"`...)
+	case math.IsInf(f, -1):
+		return append(buf, `"-Inf"`...)
+	}
+	return strconv.AppendFloat(buf, f, 'g', -1, bitSize)
+}
+
+func appendJSONString(buf []byte, s string) []byte {
+	buf = append(buf, '"')
+	buf = appendJSONEscaped(buf, s)
+	return append(buf, '"')
+}
+
+// appendJSONEscaped writes s escaped for a JSON string without quotes. Invalid UTF-8 is replaced with U+FFFD
+func appendJSONEscaped(buf []byte, s string) []byte {
+	start := 0
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c < utf8.RuneSelf {
+			if c >= 0x20 && c != '"' && c != '\\' {
+				i++
+				continue
+			}
+			buf = append(buf, s[start:i]...)
+			switch c {
+			case '"', '\\':
+				buf = append(buf, '\\', c)
+			case '\n':
+				buf = append(buf, '\\', 'n')
+			case '\r':
+				buf = append(buf, '\\', 'r')
+			case '\t':
+				buf = append(buf, '\\', 't')
+			default:
+				buf = append(buf, '\\', 'u', '0', '0', hexDigits[c>>4], hexDigits[c&0xF])
+			}
+			i++
+			start = i
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			buf = append(buf, s[start:i]...)
+			buf = append(buf, `\ufffd`...)
+			i += size
+			start = i
+			continue
+		}
+		// U+2028 and U+2029 are valid JSON but break JavaScript parsers
+		if r == '\u2028' || r == '\u2029' {
+			buf = append(buf, s[start:i]...)
+			buf = append(buf, '\\', 'u', '2', '0', '2', hexDigits[r&0xF])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	return append(buf, s[start:]...)
+}
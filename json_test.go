@@ -0,0 +1,185 @@
+package log_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gopub/log"
+)
+
+func TestJSONEncoder(t *testing.T) {
+	var buf bytes.Buffer
+	l := log.NewLogger(&buf)
+	l.SetEncoder(log.NewJSONEncoder())
+	l.SetFlags(log.Lshortfile | log.Lfunction | log.Lname)
+	l = l.Derive("db").With("id", 1, "ok", true, "tags", []string{"a"})
+	l.Info("say \"hi\"\n\tbye")
+
+	var m map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &m); err != nil {
+		t.Fatalf("unmarshal %s: %v", buf.String(), err)
+	}
+	if _, ok := m["time"]; ok {
+		t.Errorf("unexpected time without Ldate: %s", buf.String())
+	}
+	expected := map[string]interface{}{
+		"level":    "info",
+		"logger":   "db",
+		"function": "TestJSONEncoder",
+		"msg":      "say \"hi\"\n\tbye",
+		"id":       float64(1),
+		"ok":       true,
+	}
+	for k, v := range expected {
+		if m[k] != v {
+			t.Errorf("%s: expected %v, got %v", k, v, m[k])
+		}
+	}
+	if tags, ok := m["tags"].([]interface{}); !ok || len(tags) != 1 {
+		t.Errorf("tags: got %v", m["tags"])
+	}
+	if c, _ := m["caller"].(string); len(c) == 0 {
+		t.Errorf("missing caller: %s", buf.String())
+	}
+}
+
+func TestJSONEncoder_TypedFields(t *testing.T) {
+	var buf bytes.Buffer
+	l := log.NewLogger(&buf)
+	l.SetEncoder(log.NewJSONEncoder())
+	l.SetFlags(log.Lname)
+	l.LogFields(log.InfoLevel, 1, "done", []*log.Field{
+		log.String("s", "a\"b"),
+		log.Int64("i", -3),
+		log.Float64("f", 1.5),
+		log.Bool("b", true),
+		log.Duration("d", 1500*time.Millisecond),
+		log.Err(errors.New("failed")),
+		log.Time("t", time.Date(2021, 10, 25, 12, 0, 0, 0, time.UTC)),
+	})
+
+	expected := `{"level":"info","msg":"done","s":"a\"b","i":-3,"f":1.5,"b":true,"d":"1.5s","error":"failed","t":"2021-10-25T12:00:00Z"}` + "\n"
+	if buf.String() != expected {
+		t.Errorf("expected %s, got %s", expected, buf.String())
+	}
+}
+
+func TestJSONEncoder_EpochTime(t *testing.T) {
+	var buf bytes.Buffer
+	l := log.NewLogger(&buf)
+	l.SetEncoder(log.NewJSONEncoder())
+	l.SetFlags(log.Ldate | log.Ltime)
+	l.SetTimeEncoder(log.EpochMillisTimeEncoder)
+	l.Info("epoch")
+
+	var m map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &m); err != nil {
+		t.Fatalf("unmarshal %s: %v", buf.String(), err)
+	}
+	if ms, ok := m["time"].(float64); !ok || time.Since(time.Unix(0, int64(ms)*int64(time.Millisecond))) > time.Minute {
+		t.Errorf("unexpected time: %s", buf.String())
+	}
+}
+
+func TestJSONEncoder_TimeQuoting(t *testing.T) {
+	digits := log.LayoutTimeEncoder("0102")
+	negative := func(buf []byte, _ time.Time, flags int) []byte {
+		return log.EpochTimeEncoder(buf, time.Unix(-86400, 0), flags)
+	}
+	tests := []struct {
+		enc      log.TimeEncoder
+		isNumber bool
+	}{
+		{digits, false},
+		{log.EpochTimeEncoder, true},
+		{log.EpochMillisTimeEncoder, true},
+		{negative, false},
+		{log.RFC3339NanoTimeEncoder, false},
+	}
+	for i, test := range tests {
+		var buf bytes.Buffer
+		l := log.NewLogger(&buf)
+		l.SetEncoder(log.NewJSONEncoder())
+		l.SetFlags(log.Ldate)
+		l.SetTimeEncoder(test.enc)
+		l.Info("t")
+		if !json.Valid(buf.Bytes()) {
+			t.Fatalf("%d: invalid JSON %s", i, buf.String())
+		}
+		var m map[string]interface{}
+		if err := json.Unmarshal(buf.Bytes(), &m); err != nil {
+			t.Fatal(err)
+		}
+		if _, isNumber := m["time"].(float64); isNumber != test.isNumber {
+			t.Errorf("%d: expected number %t, got %s", i, test.isNumber, buf.String())
+		}
+	}
+}
+
+type nilError struct{ msg string }
+
+func (e *nilError) Error() string { return e.msg }
+
+type nilStringer struct{ s string }
+
+func (s *nilStringer) String() string { return s.s }
+
+func TestJSONEncoder_NilPointers(t *testing.T) {
+	var buf bytes.Buffer
+	l := log.NewLogger(&buf)
+	l.SetEncoder(log.NewJSONEncoder())
+	l.SetFlags(log.Lname)
+	l.Infow("a", "e", (*nilError)(nil), "s", (*nilStringer)(nil), "t", (*time.Time)(nil))
+	l.Info("after")
+	expected := `{"level":"info","msg":"a","e":null,"s":null,"t":null}` + "\n" + `{"level":"info","msg":"after"}` + "\n"
+	if buf.String() != expected {
+		t.Errorf("expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestJSONEncoder_ReservedKeys(t *testing.T) {
+	var buf bytes.Buffer
+	l := log.NewLogger(&buf)
+	l.SetEncoder(log.NewJSONEncoder())
+	l.SetFlags(log.Lname)
+	l.Infow("y", "msg", "dup", "level", 1, "id", 2)
+	expected := `{"level":"info","msg":"y","fields.msg":"dup","fields.level":1,"id":2}` + "\n"
+	if buf.String() != expected {
+		t.Errorf("expected %q, got %q", expected, buf.String())
+	}
+}
+
+type panicWriter struct {
+	bytes.Buffer
+	panics bool
+}
+
+func (w *panicWriter) Write(p []byte) (int, error) {
+	if w.panics {
+		w.panics = false
+		panic("write")
+	}
+	return w.Buffer.Write(p)
+}
+
+func TestLogger_RecoverAfterPanic(t *testing.T) {
+	w := &panicWriter{panics: true}
+	l := log.NewLogger(w)
+	l.SetEncoder(log.NewJSONEncoder())
+	l.SetFlags(log.Lname)
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("expected panic")
+			}
+		}()
+		l.Info("panic")
+	}()
+	l.Info("after")
+	if w.String() != `{"level":"info","msg":"after"}`+"\n" {
+		t.Errorf("got %q", w.String())
+	}
+}
@@ -1,5 +1,12 @@
 package log
 
+import (
+	"encoding"
+	"flag"
+	"fmt"
+	"strings"
+)
+
 type Level int
 
 const (
@@ -32,7 +39,103 @@ func (l Level) String() string {
 		return "FAT"
 	case PanicLevel:
 		return "PAN"
+	case OffLevel:
+		return "OFF"
 	default:
 		return ""
 	}
 }
+
+// name returns the lower-case full name which is used by structured encoders
+func (l Level) name() string {
+	switch l {
+	case AllLevel:
+		return "all"
+	case TraceLevel:
+		return "trace"
+	case DebugLevel:
+		return "debug"
+	case InfoLevel:
+		return "info"
+	case WarnLevel:
+		return "warn"
+	case ErrorLevel:
+		return "error"
+	case FatalLevel:
+		return "fatal"
+	case PanicLevel:
+		return "panic"
+	case OffLevel:
+		return "off"
+	default:
+		return ""
+	}
+}
+
+// ParseLevel parses level from its tag (e.g. WRN) or name (e.g. warn, warning) case-insensitively
+func ParseLevel(s string) (Level, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "all":
+		return AllLevel, nil
+	case "tra", "trace":
+		return TraceLevel, nil
+	case "deb", "debug":
+		return DebugLevel, nil
+	case "inf", "info":
+		return InfoLevel, nil
+	case "wrn", "warn", "warning":
+		return WarnLevel, nil
+	case "err", "error":
+		return ErrorLevel, nil
+	case "fat", "fatal":
+		return FatalLevel, nil
+	case "pan", "panic":
+		return PanicLevel, nil
+	case "off":
+		return OffLevel, nil
+	default:
+		return 0, fmt.Errorf("unknown level %q", s)
+	}
+}
+
+var _ flag.Getter = (*Level)(nil)
+var _ encoding.TextMarshaler = Level(0)
+var _ encoding.TextUnmarshaler = (*Level)(nil)
+
+// MarshalText marshals l into its name, e.g. warn. Level 0, which means unset, is marshaled into empty text
+func (l Level) MarshalText() ([]byte, error) {
+	if l == 0 {
+		return []byte{}, nil
+	}
+	name := l.name()
+	if name == "" {
+		return nil, fmt.Errorf("invalid level %d", int(l))
+	}
+	return []byte(name), nil
+}
+
+// UnmarshalText parses text by ParseLevel. Empty text is parsed into 0, which means unset
+func (l *Level) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*l = 0
+		return nil
+	}
+	v, err := ParseLevel(string(text))
+	if err != nil {
+		return err
+	}
+	*l = v
+	return nil
+}
+
+// Set implements flag.Value, e.g.
+// level := log.InfoLevel
+// flag.Var(&level, "log-level", "log level")
+func (l *Level) Set(s string) error {
+	return l.UnmarshalText([]byte(s))
+}
+
+// Get implements flag.Getter
+func (l *Level) Get() interface{} {
+	return *l
+}
@@ -0,0 +1,58 @@
+package log_test
+
+import (
+	"encoding/json"
+	"flag"
+	"testing"
+
+	"github.com/gopub/log"
+)
+
+func TestParseLevel(t *testing.T) {
+	tests := map[string]log.Level{
+		"WRN":     log.WarnLevel,
+		"warn":    log.WarnLevel,
+		"WARNING": log.WarnLevel,
+		" Debug ": log.DebugLevel,
+		"inf":     log.InfoLevel,
+		"off":     log.OffLevel,
+	}
+	for s, expected := range tests {
+		level, err := log.ParseLevel(s)
+		if err != nil || level != expected {
+			t.Errorf("%q: expected %v, got %v, %v", s, expected, level, err)
+		}
+	}
+	if _, err := log.ParseLevel("loud"); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestLevel_MarshalText(t *testing.T) {
+	var c struct {
+		Level log.Level `json:"level"`
+	}
+	if err := json.Unmarshal([]byte(`{"level":"ERR"}`), &c); err != nil || c.Level != log.ErrorLevel {
+		t.Fatalf("unmarshal: %v, %v", c.Level, err)
+	}
+	b, err := json.Marshal(c)
+	if err != nil || string(b) != `{"level":"error"}` {
+		t.Errorf("marshal: %s, %v", b, err)
+	}
+
+	c.Level = 0
+	if b, err = json.Marshal(c); err != nil || string(b) != `{"level":""}` {
+		t.Errorf("marshal unset: %s, %v", b, err)
+	}
+	c.Level = log.ErrorLevel
+	if err = json.Unmarshal(b, &c); err != nil || c.Level != 0 {
+		t.Errorf("unmarshal unset: %v, %v", c.Level, err)
+	}
+
+	level := log.InfoLevel
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Var(&level, "level", "log level")
+	if err := fs.Parse([]string{"-level", "trace"}); err != nil || level != log.TraceLevel {
+		t.Errorf("flag: %v, %v", level, err)
+	}
+}
@@ -0,0 +1,82 @@
+package log
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// limiter decides whether the call site at pc can log. state is shared by loggers derived from the same logger,
+// see render.limits
+type limiter interface {
+	allow(state *sync.Map, pc uintptr) bool
+}
+
+type everyKey struct {
+	pc       uintptr
+	interval time.Duration
+}
+
+type everyLimiter struct {
+	interval time.Duration
+}
+
+// allow stores the last log time in unix nanoseconds of every (call site, interval) in state
+func (e everyLimiter) allow(state *sync.Map, pc uintptr) bool {
+	v, _ := state.LoadOrStore(everyKey{pc: pc, interval: e.interval}, new(int64))
+	last := v.(*int64)
+	now := time.Now().UnixNano()
+	t := atomic.LoadInt64(last)
+	if t != 0 && now-t < int64(e.interval) {
+		return false
+	}
+	return atomic.CompareAndSwapInt64(last, t, now)
+}
+
+type onceKey struct {
+	pc  uintptr
+	key string
+}
+
+type onceLimiter struct {
+	key string
+}
+
+func (o onceLimiter) allow(state *sync.Map, pc uintptr) bool {
+	_, loaded := state.LoadOrStore(onceKey{pc: pc, key: o.key}, struct{}{})
+	return !loaded
+}
+
+// Every returns a logger which logs at most once per interval d at each call site.
+// Call sites are identified by program counter, so it's fine to call Every in a loop. The state is shared by
+// the loggers which share outputs with l, i.e. the root logger created by NewLogger and loggers derived from it.
+// Example:
+// log.Every(time.Minute).Warnf("Connect: %v", err)
+func (l *Logger) Every(d time.Duration) *Logger {
+	nl := l.derive()
+	nl.limiter = everyLimiter{interval: d}
+	return nl
+}
+
+// Once returns a logger which logs only once at each call site for key. Like Every, the state is shared by the
+// loggers which share outputs with l.
+// Example:
+// log.Once(clientVersion).Warn("Deprecated API is called")
+func (l *Logger) Once(key string) *Logger {
+	nl := l.derive()
+	nl.limiter = onceLimiter{key: key}
+	return nl
+}
+
+// allow reports whether the caller at callDepth can log according to l.limiter
+func (l *Logger) allow(callDepth int) bool {
+	if l.limiter == nil {
+		return true
+	}
+	pc, _, _, ok := runtime.Caller(callDepth)
+	if !ok {
+		return true
+	}
+	return l.limiter.allow(&l.render.limits, pc)
+}
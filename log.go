@@ -5,27 +5,30 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
-type Field struct {
-	Key   string
-	Value interface{}
-}
-
-var defaultLogger *Logger
+var defaultLogger atomic.Value // *Logger
 
 func init() {
+	defaultLogger.Store(newDefaultLogger())
+	if err := ConfigFromEnv().Apply(); err != nil {
+		log.Printf("Configure from environment: %v\n", err)
+	}
+}
+
+func newDefaultLogger() *Logger {
 	dir := os.Getenv("LOG_DIR")
 	if dir == "" {
-		defaultLogger = NewLogger(os.Stderr)
-		return
+		return NewLogger(os.Stderr)
 	}
 
 	fw, err := NewFileWriter(dir)
 	if err != nil {
 		log.Printf("Create file writer: %v\n", err)
-		defaultLogger = NewLogger(os.Stderr)
-		return
+		return NewLogger(os.Stderr)
 	}
 
 	if s := os.Getenv("LOG_ROTATE_KEEP"); s != "" {
@@ -46,112 +49,204 @@ func init() {
 		}
 	}
 
-	defaultLogger = NewLogger(fw)
+	return NewLogger(fw)
 }
 
 func Default() *Logger {
-	return defaultLogger
+	return defaultLogger.Load().(*Logger)
 }
 
+// SetDefault replaces the default logger, it's safe to call while other goroutines are logging
 func SetDefault(l *Logger) {
-	defaultLogger = l
+	defaultLogger.Store(l)
 }
 
-var _level = AllLevel
-var _flags = LstdFlags
+// package-level settings are accessed atomically, so they can be changed while other goroutines are logging
+var _level = int32(AllLevel)
+var _flags = int32(LstdFlags)
 
 func SetLevel(level Level) Level {
-	_level = level
+	atomic.StoreInt32(&_level, int32(level))
 	return level
 }
 
 func GetLevel() Level {
-	return _level
+	return Level(atomic.LoadInt32(&_level))
 }
 
 func SetFlags(flags int) {
-	_flags = flags
+	atomic.StoreInt32(&_flags, int32(flags))
 }
 
 func Flags() int {
-	return _flags
+	return int(atomic.LoadInt32(&_flags))
+}
+
+var _exitFunc atomic.Value // func(code int)
+var _exitHandlers struct {
+	sync.Mutex
+	list []func()
+}
+
+// SetExitFunc sets the function called by Fatal* functions and methods of loggers without own exit function.
+// nil means os.Exit
+func SetExitFunc(f func(code int)) {
+	if f == nil {
+		f = os.Exit
+	}
+	_exitFunc.Store(f)
+}
+
+func exitFunc() func(code int) {
+	if f, ok := _exitFunc.Load().(func(code int)); ok {
+		return f
+	}
+	return os.Exit
+}
+
+// AddExitHandler adds a handler which is run by all loggers before Fatal* exits and Panic* panics
+func AddExitHandler(h func()) {
+	_exitHandlers.Lock()
+	_exitHandlers.list = append(_exitHandlers.list[:len(_exitHandlers.list):len(_exitHandlers.list)], h)
+	_exitHandlers.Unlock()
+}
+
+func exitHandlers() []func() {
+	_exitHandlers.Lock()
+	defer _exitHandlers.Unlock()
+	return _exitHandlers.list
+}
+
+var _strictFields int32
+
+// SetStrictFields makes With and the *w logging methods panic on malformed key-value pairs, which is useful in tests.
+// By default, malformed pairs are logged as field !BADKEY or value !MISSING.
+func SetStrictFields(strict bool) {
+	var v int32
+	if strict {
+		v = 1
+	}
+	atomic.StoreInt32(&_strictFields, v)
+}
+
+func strictFields() bool {
+	return atomic.LoadInt32(&_strictFields) != 0
 }
 
 func GetLogger(name string) *Logger {
-	return defaultLogger.Derive(name)
+	return Default().Derive(name)
+}
+
+func Every(d time.Duration) *Logger {
+	return Default().Every(d)
+}
+
+func Once(key string) *Logger {
+	return Default().Once(key)
 }
 
 func WithFields(fields []*Field) *Logger {
-	return defaultLogger.WithFields(fields)
+	return Default().WithFields(fields)
 }
 
 func With(keyValues ...interface{}) *Logger {
-	return defaultLogger.With(keyValues...)
+	return Default().With(keyValues...)
 }
 
 func Trace(args ...interface{}) {
-	defaultLogger.Log(TraceLevel, 2, args)
+	Default().Log(TraceLevel, 2, args)
 }
 
 func Debug(args ...interface{}) {
-	defaultLogger.Log(DebugLevel, 2, args)
+	Default().Log(DebugLevel, 2, args)
 }
 
 func Info(args ...interface{}) {
-	defaultLogger.Log(InfoLevel, 2, args)
+	Default().Log(InfoLevel, 2, args)
 }
 
 func Warn(args ...interface{}) {
-	defaultLogger.Log(WarnLevel, 2, args)
+	Default().Log(WarnLevel, 2, args)
 }
 
 func Error(args ...interface{}) {
-	defaultLogger.Log(ErrorLevel, 2, args)
+	Default().Log(ErrorLevel, 2, args)
 }
 
 func Fatal(args ...interface{}) {
-	defaultLogger.Log(FatalLevel, 2, args)
-	os.Exit(1)
+	Default().Log(FatalLevel, 2, args)
+	Default().exit(1)
 }
 
 func Panic(args ...interface{}) {
 	msg := fmt.Sprintln(args...)
 	msg = msg[0 : len(msg)-1]
-	l := defaultLogger
-	e := newEntry(l.Flags(), PanicLevel, l.name, l.fields, msg, 2)
-	panic(l.render.RenderString(e))
+	l := Default()
+	e := l.newEntry(PanicLevel, l.fields, msg, 2)
+	l.panic(e)
 }
 
 func Tracef(format string, args ...interface{}) {
-	defaultLogger.Logf(TraceLevel, 2, format, args)
+	Default().Logf(TraceLevel, 2, format, args)
 }
 
 func Debugf(format string, args ...interface{}) {
-	defaultLogger.Logf(DebugLevel, 2, format, args)
+	Default().Logf(DebugLevel, 2, format, args)
 }
 
 func Infof(format string, args ...interface{}) {
-	defaultLogger.Logf(InfoLevel, 2, format, args)
+	Default().Logf(InfoLevel, 2, format, args)
 }
 
 func Warnf(format string, args ...interface{}) {
-	defaultLogger.Logf(WarnLevel, 2, format, args)
+	Default().Logf(WarnLevel, 2, format, args)
 }
 
 func Errorf(format string, args ...interface{}) {
-	defaultLogger.Logf(ErrorLevel, 2, format, args)
+	Default().Logf(ErrorLevel, 2, format, args)
 }
 
 func Fatalf(format string, args ...interface{}) {
-	defaultLogger.Logf(FatalLevel, 2, format, args)
-	os.Exit(1)
+	Default().Logf(FatalLevel, 2, format, args)
+	Default().exit(1)
 }
 
 func Panicf(format string, args ...interface{}) {
 	msg := fmt.Sprintf(format, args...)
-	l := defaultLogger
-	e := newEntry(l.Flags(), PanicLevel, l.name, l.fields, msg, 2)
-	panic(l.render.RenderString(e))
+	l := Default()
+	e := l.newEntry(PanicLevel, l.fields, msg, 2)
+	l.panic(e)
+}
+
+func Tracew(msg string, keyValues ...interface{}) {
+	Default().logw(TraceLevel, 2, msg, keyValues)
+}
+
+func Debugw(msg string, keyValues ...interface{}) {
+	Default().logw(DebugLevel, 2, msg, keyValues)
+}
+
+func Infow(msg string, keyValues ...interface{}) {
+	Default().logw(InfoLevel, 2, msg, keyValues)
+}
+
+func Warnw(msg string, keyValues ...interface{}) {
+	Default().logw(WarnLevel, 2, msg, keyValues)
+}
+
+func Errorw(msg string, keyValues ...interface{}) {
+	Default().logw(ErrorLevel, 2, msg, keyValues)
+}
+
+func Fatalw(msg string, keyValues ...interface{}) {
+	Default().logw(FatalLevel, 2, msg, keyValues)
+	Default().exit(1)
+}
+
+func Panicw(msg string, keyValues ...interface{}) {
+	l := Default()
+	e := l.newEntry(PanicLevel, l.entryFields(makeFields(keyValues...)), msg, 2)
+	l.panic(e)
 }
 
 func ErrorE(err error) {
@@ -0,0 +1,161 @@
+package log
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+// LogfmtEncoder encodes an entry as a logfmt line, e.g.
+// time="2021-10-25 12:00:00.000+0800" level=info logger=db caller=l/logger.go:12 function=Query msg="query done" id=1
+// Values containing spaces, quotes, '=' or control characters are quoted. Fields colliding with keys of entry
+// attributes are renamed like JSONEncoder does, e.g. fields.msg.
+type LogfmtEncoder struct{}
+
+var _ Encoder = (*LogfmtEncoder)(nil)
+
+func NewLogfmtEncoder() *LogfmtEncoder {
+	return &LogfmtEncoder{}
+}
+
+func (l *LogfmtEncoder) Encode(buf []byte, e *Entry) []byte {
+	var tb [64]byte
+	t := tb[:0]
+	t = e.AppendTime(t)
+	if len(t) > 0 {
+		buf = append(buf, "time="...)
+		buf = appendLogfmtString(buf, string(t))
+		buf = append(buf, ' ')
+	}
+
+	buf = append(buf, "level="...)
+	buf = append(buf, e.Level.name()...)
+
+	if e.Flags&Lname != 0 && len(e.Name) > 0 {
+		buf = append(buf, " logger="...)
+		buf = appendLogfmtString(buf, e.Name)
+	}
+
+	if len(e.File) > 0 {
+		buf = append(buf, " caller="...)
+		if needsLogfmtQuote(e.File) {
+			buf = appendLogfmtString(buf, e.File+":"+strconv.Itoa(e.Line))
+		} else {
+			buf = append(buf, e.File...)
+			buf = append(buf, ':')
+			buf = strconv.AppendInt(buf, int64(e.Line), 10)
+		}
+	}
+
+	if len(e.Function) > 0 {
+		buf = append(buf, " function="...)
+		buf = appendLogfmtString(buf, e.Function)
+		if len(e.File) == 0 {
+			buf = append(buf, " line="...)
+			buf = strconv.AppendInt(buf, int64(e.Line), 10)
+		}
+	}
+
+	buf = append(buf, " msg="...)
+	buf = appendLogfmtString(buf, e.Message)
+
+	for _, f := range e.Fields {
+		buf = append(buf, ' ')
+		buf = appendLogfmtKey(buf, fieldKey(f.Key))
+		buf = append(buf, '=')
+		buf = appendLogfmtField(buf, f)
+	}
+	return append(buf, '\n')
+}
+
+// appendLogfmtKey replaces characters which are not allowed in a logfmt key with '_'
+func appendLogfmtKey(buf []byte, key string) []byte {
+	if len(key) == 0 {
+		return append(buf, '_')
+	}
+	for _, r := range key {
+		if r <= ' ' || r == '=' || r == '"' || r == utf8.RuneError {
+			buf = append(buf, '_')
+		} else {
+			buf = append(buf, string(r)...)
+		}
+	}
+	return buf
+}
+
+func appendLogfmtField(buf []byte, f *Field) []byte {
+	switch f.kind {
+	case stringKind:
+		return appendLogfmtString(buf, f.str)
+	case anyKind, errorKind:
+		return appendLogfmtValue(buf, f.Value)
+	default:
+		return f.appendText(buf)
+	}
+}
+
+func appendLogfmtValue(buf []byte, v interface{}) []byte {
+	switch v := v.(type) {
+	case nil:
+		return append(buf, "<nil>"...)
+	case string:
+		return appendLogfmtString(buf, v)
+	case bool:
+		return strconv.AppendBool(buf, v)
+	case int:
+		return strconv.AppendInt(buf, int64(v), 10)
+	case int8:
+		return strconv.AppendInt(buf, int64(v), 10)
+	case int16:
+		return strconv.AppendInt(buf, int64(v), 10)
+	case int32:
+		return strconv.AppendInt(buf, int64(v), 10)
+	case int64:
+		return strconv.AppendInt(buf, v, 10)
+	case uint:
+		return strconv.AppendUint(buf, uint64(v), 10)
+	case uint8:
+		return strconv.AppendUint(buf, uint64(v), 10)
+	case uint16:
+		return strconv.AppendUint(buf, uint64(v), 10)
+	case uint32:
+		return strconv.AppendUint(buf, uint64(v), 10)
+	case uint64:
+		return strconv.AppendUint(buf, v, 10)
+	case float32:
+		return strconv.AppendFloat(buf, float64(v), 'g', -1, 32)
+	case float64:
+		return strconv.AppendFloat(buf, v, 'g', -1, 64)
+	case time.Duration:
+		return append(buf, v.String()...)
+	case time.Time:
+		return append(buf, v.Format(time.RFC3339Nano)...)
+	case error:
+		return appendLogfmtString(buf, methodString(v, "Error", func() string { return v.Error() }))
+	case fmt.Stringer:
+		return appendLogfmtString(buf, methodString(v, "String", func() string { return v.String() }))
+	default:
+		return appendLogfmtString(buf, fmt.Sprintf("%+v", v))
+	}
+}
+
+// appendLogfmtString writes s, quoting it if it is empty or contains spaces, quotes, '=' or control characters
+func appendLogfmtString(buf []byte, s string) []byte {
+	if !needsLogfmtQuote(s) {
+		return append(buf, s...)
+	}
+	return appendJSONString(buf, s)
+}
+
+func needsLogfmtQuote(s string) bool {
+	if len(s) == 0 {
+		return true
+	}
+	for _, r := range s {
+		if r <= ' ' || r == '=' || r == '"' || r == '\\' || r == 0x7f || r == utf8.RuneError {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,36 @@
+package log_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/gopub/log"
+)
+
+func TestLogfmtEncoder(t *testing.T) {
+	var buf bytes.Buffer
+	l := log.NewLogger(&buf)
+	l.SetEncoder(log.NewLogfmtEncoder())
+	l.SetFlags(log.Lname)
+	l = l.Derive("db").With("userID", 1, "query", "a=b c", "quote", `say "hi"`, "plain", "ok")
+	l.Warn("query failed")
+
+	expected := `level=warn logger=db msg="query failed" userID=1 query="a=b c" quote="say \"hi\"" plain=ok` + "\n"
+	if buf.String() != expected {
+		t.Errorf("expected %s, got %s", expected, buf.String())
+	}
+}
+
+func TestLogfmtEncoder_NilPointers(t *testing.T) {
+	var buf bytes.Buffer
+	l := log.NewLogger(&buf)
+	l.SetEncoder(log.NewLogfmtEncoder())
+	l.SetFlags(log.Lname)
+	var err *nilError
+	l.LogFields(log.InfoLevel, 1, "a", []*log.Field{log.Err(err), log.Any("s", (*nilStringer)(nil)), log.Any("msg", "dup")})
+	l.Info("after")
+	expected := "level=info msg=a error=<nil> s=<nil> fields.msg=dup\nlevel=info msg=after\n"
+	if buf.String() != expected {
+		t.Errorf("expected %q, got %q", expected, buf.String())
+	}
+}
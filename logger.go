@@ -6,6 +6,8 @@ import (
 	"log"
 	"os"
 	"strings"
+	"sync/atomic"
+	"time"
 )
 
 var PackagePath = func() string {
@@ -23,11 +25,18 @@ const GoSrc = "/go/src/"
 
 //Logger is the default implementation of *Logger interface
 type Logger struct {
-	name   string
-	level  Level
-	flags  int
+	// name, level, flags and timeEncoder are accessed atomically, so they can be changed while logging
+	name   atomic.Value // string
+	level  int32
+	flags  int32
 	render *render
 	fields []*Field
+
+	timeEncoder  atomic.Value // TimeEncoder
+	limiter      limiter
+	ctxFields    []*Field // fields of the context which l is created from, they prefix fields of inner contexts
+	exitFunc     func(code int)
+	exitHandlers []func()
 }
 
 func NewLogger(output io.Writer) *Logger {
@@ -38,68 +47,253 @@ func NewLogger(output io.Writer) *Logger {
 }
 
 func (l *Logger) Name() string {
-	return l.name
+	name, _ := l.name.Load().(string)
+	return name
 }
 
 func (l *Logger) SetName(name string) {
-	l.name = name
+	l.name.Store(name)
+	registry.register(name, l)
 }
 
+// Level returns the effective level, which is the override of l's name (see SetLoggerLevel) if any,
+// or l's own level if set, otherwise the package-level one
 func (l *Logger) Level() Level {
-	if l.level >= AllLevel {
-		return l.level
+	if level, ok := registry.Lookup(l.Name()); ok {
+		return level
+	}
+	if level := Level(atomic.LoadInt32(&l.level)); level >= AllLevel {
+		return level
 	}
-	return _level
+	return GetLevel()
 }
 
 func (l *Logger) SetLevel(level Level) {
-	l.level = level
+	atomic.StoreInt32(&l.level, int32(level))
 }
 
 func (l *Logger) Flags() int {
-	if l.flags > 0 {
-		return l.flags
+	if flags := atomic.LoadInt32(&l.flags); flags > 0 {
+		return int(flags)
 	}
-	return _flags
+	return Flags()
 }
 
 func (l *Logger) SetFlags(flags int) {
-	l.flags = flags
+	atomic.StoreInt32(&l.flags, int32(flags))
+}
+
+func (l *Logger) TimeEncoder() TimeEncoder {
+	if enc, _ := l.timeEncoder.Load().(TimeEncoder); enc != nil {
+		return enc
+	}
+	return timeEncoder()
+}
+
+// SetTimeEncoder sets the encoding of entry time, e.g. RFC3339NanoTimeEncoder. nil means the package-level one,
+// see SetTimeEncoder
+func (l *Logger) SetTimeEncoder(enc TimeEncoder) {
+	l.timeEncoder.Store(enc)
+}
+
+// SetEncoder sets the encoder shared by l and the loggers derived from it, outputs added with their own encoder
+// are not affected. nil means the default text encoder
+func (l *Logger) SetEncoder(enc Encoder) {
+	l.render.SetEncoder(enc)
+}
+
+// SetFormatter sets the formatter shared by l and the loggers derived from it, it's a shortcut of SetEncoder
+// for custom text layouts. nil means TextFormatter
+func (l *Logger) SetFormatter(f Formatter) {
+	l.render.SetEncoder(encoderOf(f))
 }
 
 func (l *Logger) AddOutput(w io.Writer) {
-	l.render.AddOutput(w)
+	l.render.AddOutput(w, AllLevel, nil)
+}
+
+// AddLeveledOutput adds an output which only receives entries at level or above, encoded by enc.
+// nil enc means the logger's encoder. Entries are encoded once per distinct encoder, not once per output.
+// Example:
+// l.AddLeveledOutput(os.Stderr, log.DebugLevel, nil)
+// l.AddLeveledOutput(fw, log.WarnLevel, log.NewJSONEncoder())
+func (l *Logger) AddLeveledOutput(w io.Writer, level Level, enc Encoder) {
+	l.render.AddOutput(w, level, enc)
 }
 
 func (l *Logger) RemoveOutput(w io.Writer) {
 	l.render.RemoveOutput(w)
 }
 
+// AddHook adds a hook which is shared by l and the loggers derived from it
+func (l *Logger) AddHook(h Hook) {
+	l.render.AddHook(h)
+}
+
+// Sync flushes buffered data of all outputs which implement Flush() error or Sync() error
+func (l *Logger) Sync() error {
+	if sampler := l.render.Sampler(); sampler != nil {
+		logSampleSummaries(sampler.flush(), sampler.interval)
+	}
+	return l.render.Sync()
+}
+
+// SetSampler sets the sampler shared by l and the loggers derived from it. nil means no sampling
+func (l *Logger) SetSampler(s *Sampler) {
+	l.render.SetSampler(s)
+}
+
+// sample reports whether an entry should be logged according to the sampler, and logs summaries of suppressed
+// entries if there are any
+func (l *Logger) sample(level Level, template string) bool {
+	sampler := l.render.Sampler()
+	if sampler == nil {
+		return true
+	}
+	ok, summaries := sampler.sample(l, level, template)
+	logSampleSummaries(summaries, sampler.interval)
+	return ok
+}
+
+// logSampleSummaries logs each summary with the logger which logged the suppressed entries. Summaries have no
+// caller, as they are not logged at the call sites of suppressed entries
+func logSampleSummaries(summaries []sampleSummary, interval time.Duration) {
+	for _, s := range summaries {
+		l := s.logger
+		e := newEntryAt(l.Flags(), s.key.level, l.Name(), l.fields, s.message(interval), time.Now())
+		e.timeEncoder = l.TimeEncoder()
+		if err := l.render.Render(e); err != nil {
+			log.Printf("Render: %v\n", err)
+		}
+	}
+}
+
+// Close flushes and closes all outputs which implement io.Closer except os.Stdout and os.Stderr.
+// As outputs are shared by derived loggers, it's usually called on the root logger before the program exits.
+func (l *Logger) Close() error {
+	return l.render.Close()
+}
+
+// SetExitFunc sets the function called by Fatal* methods after logging, e.g. to intercept exits in tests.
+// If f returns, the Fatal* call returns too. nil means the package-level exit function, see SetExitFunc.
+func (l *Logger) SetExitFunc(f func(code int)) {
+	l.exitFunc = f
+}
+
+// AddExitHandler adds a handler which is run before Fatal* methods exit and Panic* methods panic,
+// e.g. to flush metrics. Handlers added to l are not run by loggers derived before.
+func (l *Logger) AddExitHandler(h func()) {
+	// full slice expression prevents appending into the array shared with derived loggers
+	l.exitHandlers = append(l.exitHandlers[:len(l.exitHandlers):len(l.exitHandlers)], h)
+}
+
+func (l *Logger) runExitHandlers() {
+	for _, h := range l.exitHandlers {
+		h()
+	}
+	for _, h := range exitHandlers() {
+		h()
+	}
+}
+
+// exit runs exit handlers and syncs outputs before exiting, so that the fatal entry won't be lost
+func (l *Logger) exit(code int) {
+	l.runExitHandlers()
+	if err := l.Sync(); err != nil {
+		log.Printf("Sync: %v\n", err)
+	}
+	if l.exitFunc != nil {
+		l.exitFunc(code)
+	} else {
+		exitFunc()(code)
+	}
+}
+
+// panic runs exit handlers and panics with e in plain text
+func (l *Logger) panic(e *Entry) {
+	l.render.fire(e)
+	msg := l.render.RenderString(e)
+	l.runExitHandlers()
+	panic(msg)
+}
+
+func (l *Logger) newEntry(level Level, fields []*Field, msg string, callDepth int) *Entry {
+	e := newEntry(l.Flags(), level, l.Name(), fields, msg, callDepth+1)
+	e.timeEncoder = l.TimeEncoder()
+	return e
+}
+
 func (l *Logger) Log(level Level, callDepth int, args []interface{}) {
-	if l.Level() > level {
+	if l.Level() > level || !l.allow(callDepth+1) {
 		return
 	}
 
 	// fmt.Sprint won't add space between args, fmt.Sprintln will do, but need to erase extra newline
 	msg := fmt.Sprintln(args...)
 	msg = msg[0 : len(msg)-1]
-	err := l.render.Render(newEntry(l.Flags(), level, l.name, l.fields, msg, callDepth+1))
+	if !l.sample(level, msg) {
+		return
+	}
+	err := l.render.Render(l.newEntry(level, l.fields, msg, callDepth+1))
 	if err != nil {
 		log.Printf("Render: %v\n", err)
 	}
 }
 
 func (l *Logger) Logf(level Level, callDepth int, format string, args []interface{}) {
-	if l.Level() > level {
+	if l.Level() > level || !l.allow(callDepth+1) {
+		return
+	}
+	if !l.sample(level, format) {
 		return
 	}
 	msg := fmt.Sprintf(format, args...)
-	err := l.render.Render(newEntry(l.Flags(), level, l.name, l.fields, msg, callDepth+1))
+	err := l.render.Render(l.newEntry(level, l.fields, msg, callDepth+1))
 	if err != nil {
 		log.Printf("Render: %v\n", err)
 	}
 }
 
+// LogFields logs msg with fields attached to this entry only, besides the logger's fields.
+// Example:
+// l.LogFields(log.InfoLevel, 1, "request done", []*log.Field{log.String("path", path), log.Duration("cost", cost)})
+func (l *Logger) LogFields(level Level, callDepth int, msg string, fields []*Field) {
+	if l.Level() > level || !l.allow(callDepth+1) {
+		return
+	}
+	l.logFields(level, callDepth+1, msg, fields)
+}
+
+func (l *Logger) logw(level Level, callDepth int, msg string, keyValues []interface{}) {
+	if l.Level() > level || !l.allow(callDepth+1) {
+		return
+	}
+	l.logFields(level, callDepth+1, msg, makeFields(keyValues...))
+}
+
+func (l *Logger) logFields(level Level, callDepth int, msg string, fields []*Field) {
+	if !l.sample(level, msg) {
+		return
+	}
+	err := l.render.Render(l.newEntry(level, l.entryFields(fields), msg, callDepth+1))
+	if err != nil {
+		log.Printf("Render: %v\n", err)
+	}
+}
+
+// entryFields returns the logger's fields followed by fields without modifying l.fields
+func (l *Logger) entryFields(fields []*Field) []*Field {
+	if len(fields) == 0 {
+		return l.fields
+	}
+	if len(l.fields) == 0 {
+		return fields
+	}
+	all := make([]*Field, 0, len(l.fields)+len(fields))
+	all = append(all, l.fields...)
+	return append(all, fields...)
+}
+
 func (l *Logger) Trace(args ...interface{}) {
 	l.Log(TraceLevel, 2, args)
 }
@@ -122,18 +316,18 @@ func (l *Logger) Error(args ...interface{}) {
 
 func (l *Logger) Fatal(args ...interface{}) {
 	l.Log(FatalLevel, 2, args)
-	os.Exit(1)
+	l.exit(1)
 }
 
 func (l *Logger) Panic(args ...interface{}) {
-	if l.level > PanicLevel {
+	if Level(atomic.LoadInt32(&l.level)) > PanicLevel {
 		return
 	}
 	// fmt.Sprint won't add space between args
 	msg := fmt.Sprintln(args...)
 	msg = msg[0 : len(msg)-1]
-	e := newEntry(l.Flags(), PanicLevel, l.name, l.fields, msg, 2)
-	panic(l.render.RenderString(e))
+	e := l.newEntry(PanicLevel, l.fields, msg, 2)
+	l.panic(e)
 }
 
 func (l *Logger) Tracef(format string, args ...interface{}) {
@@ -158,29 +352,77 @@ func (l *Logger) Errorf(format string, args ...interface{}) {
 
 func (l *Logger) Fatalf(format string, args ...interface{}) {
 	l.Logf(FatalLevel, 2, format, args)
-	os.Exit(1)
+	l.exit(1)
 }
 
 func (l *Logger) Panicf(format string, args ...interface{}) {
-	if l.level > PanicLevel {
+	if Level(atomic.LoadInt32(&l.level)) > PanicLevel {
 		return
 	}
 	msg := fmt.Sprintf(format, args...)
-	e := newEntry(l.Flags(), PanicLevel, l.name, l.fields, msg, 2)
-	panic(l.render.RenderString(e))
+	e := l.newEntry(PanicLevel, l.fields, msg, 2)
+	l.panic(e)
 }
 
-func (l *Logger) WithFields(fields []*Field) *Logger {
+// Tracew logs msg with fields made of keyValues, which are attached to this entry only.
+// keyValues are pairs of (string, interface{}) or *Field
+func (l *Logger) Tracew(msg string, keyValues ...interface{}) {
+	l.logw(TraceLevel, 2, msg, keyValues)
+}
+
+func (l *Logger) Debugw(msg string, keyValues ...interface{}) {
+	l.logw(DebugLevel, 2, msg, keyValues)
+}
+
+func (l *Logger) Infow(msg string, keyValues ...interface{}) {
+	l.logw(InfoLevel, 2, msg, keyValues)
+}
+
+func (l *Logger) Warnw(msg string, keyValues ...interface{}) {
+	l.logw(WarnLevel, 2, msg, keyValues)
+}
+
+func (l *Logger) Errorw(msg string, keyValues ...interface{}) {
+	l.logw(ErrorLevel, 2, msg, keyValues)
+}
+
+func (l *Logger) Fatalw(msg string, keyValues ...interface{}) {
+	l.logw(FatalLevel, 2, msg, keyValues)
+	l.exit(1)
+}
+
+func (l *Logger) Panicw(msg string, keyValues ...interface{}) {
+	if Level(atomic.LoadInt32(&l.level)) > PanicLevel {
+		return
+	}
+	e := l.newEntry(PanicLevel, l.entryFields(makeFields(keyValues...)), msg, 2)
+	l.panic(e)
+}
+
+// derive returns a copy of l sharing the same render
+func (l *Logger) derive() *Logger {
 	nl := &Logger{
-		name:   l.name,
-		level:  l.level,
-		flags:  l.flags,
+		level:  atomic.LoadInt32(&l.level),
+		flags:  atomic.LoadInt32(&l.flags),
 		render: l.render,
-	}
 
+		limiter:      l.limiter,
+		ctxFields:    l.ctxFields,
+		exitFunc:     l.exitFunc,
+		exitHandlers: l.exitHandlers,
+	}
+	nl.name.Store(l.Name())
+	if enc, _ := l.timeEncoder.Load().(TimeEncoder); enc != nil {
+		nl.timeEncoder.Store(enc)
+	}
 	//in case of overlapping after multiple WithFields invokes
 	nl.fields = make([]*Field, len(l.fields))
 	copy(nl.fields, l.fields)
+	return nl
+}
+
+func (l *Logger) WithFields(fields []*Field) *Logger {
+	nl := l.derive()
 	nl.fields = append(nl.fields, fields...)
 	return nl
 }
@@ -190,52 +432,59 @@ func (l *Logger) With(keyValues ...interface{}) *Logger {
 }
 
 func (l *Logger) Derive(name string) *Logger {
-	nl := &Logger{
-		name:   l.name,
-		level:  l.level,
-		flags:  l.flags,
-		render: l.render,
-	}
-
+	nl := l.derive()
 	if len(name) > 0 {
-		nl.name = name
+		nl.SetName(name)
 	}
-
-	//in case of overlapping after multiple WithFields invokes
-	nl.fields = make([]*Field, len(l.fields))
-	copy(nl.fields, l.fields)
 	return nl
 }
 
+const (
+	badKey       = "!BADKEY"
+	missingValue = "!MISSING"
+)
+
+// makeFields converts keyValues into fields. keyValues are pairs of (string, interface{}) or *Field.
+// Malformed keyValues don't panic unless strict mode is on, instead problems are recorded in fields:
+// a non-string key becomes the value of field !BADKEY, a key without value gets value !MISSING.
 func makeFields(keyValues ...interface{}) []*Field {
 	n := len(keyValues)
-	if n%2 != 0 {
-		defaultLogger.Panic("keyValues should be pairs of (string, interface{})", keyValues)
-	}
-
 	fields := make([]*Field, 0, n/2)
-	for i := 0; i < n/2; i++ {
-		if isEmptyString(keyValues[2*i+1]) {
+	for i := 0; i < n; {
+		if f, ok := keyValues[i].(*Field); ok {
+			fields = append(fields, f)
+			i++
+			continue
+		}
+
+		k, ok := keyValues[i].(string)
+		if !ok {
+			if strictFields() {
+				Default().Panicf("keyValues[%d] isn't convertible to string", i)
+			}
+			fields = append(fields, &Field{Key: badKey, Value: keyValues[i]})
+			i++
 			continue
 		}
 
-		if k, ok := keyValues[2*i].(string); !ok {
-			defaultLogger.Panicf("keyValues[%d] isn't convertible to string", i)
-		} else if keyValues[2*i+1] == nil {
-			defaultLogger.Panicf("keyValues[%d] is nil", 2*i+1)
-		} else {
-			fields = append(fields, &Field{k, keyValues[2*i+1]})
+		if i == n-1 {
+			if strictFields() {
+				Default().Panic("keyValues should be pairs of (string, interface{})", keyValues)
+			}
+			fields = append(fields, &Field{Key: k, Value: missingValue})
+			break
+		}
+
+		if v := keyValues[i+1]; !isEmptyString(v) {
+			fields = append(fields, &Field{Key: k, Value: v})
 		}
+		i += 2
 	}
 
 	return fields
 }
 
 func isEmptyString(i interface{}) bool {
-	if i == nil {
-		return true
-	}
-
 	if s, ok := i.(string); ok && s == "" {
 		return true
 	}
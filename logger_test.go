@@ -0,0 +1,303 @@
+package log_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gopub/log"
+)
+
+func TestLogger_AddLeveledOutput(t *testing.T) {
+	var text, js bytes.Buffer
+	l := log.NewLogger(&text)
+	l.SetFlags(log.Lname)
+	l.AddLeveledOutput(&js, log.WarnLevel, log.NewJSONEncoder())
+	l.Info("info")
+	l.Error("error")
+
+	if n := strings.Count(text.String(), "\n"); n != 2 {
+		t.Errorf("expected 2 text lines, got %d: %s", n, text.String())
+	}
+	expected := `{"level":"error","msg":"error"}` + "\n"
+	if js.String() != expected {
+		t.Errorf("expected %s, got %s", expected, js.String())
+	}
+}
+
+func TestLogger_Infow(t *testing.T) {
+	var buf bytes.Buffer
+	l := log.NewLogger(&buf)
+	l.SetEncoder(log.NewLogfmtEncoder())
+	l.SetFlags(log.Lfunction)
+	l = l.With("service", "api")
+	l.Infow("done", "status", 200, log.Int("size", 5), "path", "/")
+	l.Info("next")
+
+	lines := strings.Split(buf.String(), "\n")
+	if !strings.Contains(lines[0], ".TestLogger_Infow line=") ||
+		!strings.HasSuffix(lines[0], `msg=done service=api status=200 size=5 path=/`) {
+		t.Errorf("unexpected: %s", lines[0])
+	}
+	if !strings.HasSuffix(lines[1], "msg=next service=api") {
+		t.Errorf("unexpected: %s", lines[1])
+	}
+}
+
+func TestLogger_With_BadKeyValues(t *testing.T) {
+	var buf bytes.Buffer
+	l := log.NewLogger(&buf)
+	l.SetEncoder(log.NewLogfmtEncoder())
+	l.SetFlags(log.Lname)
+	l.With(1, "a", "b", "n", nil, "c").Info("bad")
+
+	expected := "level=info msg=bad !BADKEY=1 a=b n=<nil> c=!MISSING\n"
+	if buf.String() != expected {
+		t.Errorf("expected %s, got %s", expected, buf.String())
+	}
+
+	log.SetStrictFields(true)
+	defer log.SetStrictFields(false)
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic in strict mode")
+		}
+	}()
+	l.With("a")
+}
+
+func TestLogger_SetExitFunc(t *testing.T) {
+	var buf bytes.Buffer
+	l := log.NewLogger(&buf)
+	code := 0
+	l.SetExitFunc(func(c int) {
+		code = c
+	})
+	handled := 0
+	l.AddExitHandler(func() {
+		handled++
+	})
+	l.Derive("sub").Fatal("fatal")
+	if code != 1 || handled != 1 {
+		t.Errorf("expected exit code 1 and 1 handler call, got %d and %d", code, handled)
+	}
+	if !strings.Contains(buf.String(), "[FAT]") {
+		t.Errorf("missing fatal entry: %s", buf.String())
+	}
+
+	defer func() {
+		if recover() == nil || handled != 2 {
+			t.Errorf("expected panic after handler, handled %d", handled)
+		}
+	}()
+	l.Panic("panic")
+}
+
+type countHook struct {
+	counts map[string]int
+}
+
+func (h *countHook) Levels() []log.Level {
+	return []log.Level{log.ErrorLevel}
+}
+
+func (h *countHook) Fire(e *log.Entry) error {
+	h.counts[e.Name]++
+	return nil
+}
+
+func TestLogger_AddHook(t *testing.T) {
+	var buf bytes.Buffer
+	l := log.NewLogger(&buf)
+	h := &countHook{counts: map[string]int{}}
+	l.AddHook(h)
+	db := l.Derive("db")
+	db.Error("1")
+	db.Error("2")
+	db.Info("3")
+	l.Derive("http").Errorf("%d", 4)
+	if h.counts["db"] != 2 || h.counts["http"] != 1 {
+		t.Errorf("unexpected counts: %v", h.counts)
+	}
+}
+
+type valueHook struct {
+	values map[string]interface{}
+}
+
+func (h *valueHook) Levels() []log.Level {
+	return []log.Level{log.InfoLevel}
+}
+
+func (h *valueHook) Fire(e *log.Entry) error {
+	for _, f := range e.Fields {
+		h.values[f.Key] = f.Interface()
+	}
+	return nil
+}
+
+func TestField_Interface(t *testing.T) {
+	var buf bytes.Buffer
+	l := log.NewLogger(&buf)
+	h := &valueHook{values: map[string]interface{}{}}
+	l.AddHook(h)
+	l.LogFields(log.InfoLevel, 1, "typed", []*log.Field{
+		log.String("s", "v"), log.Int("i", 1), log.Bool("b", true), log.Duration("d", time.Second),
+		{Key: "k", Value: 2.5},
+	})
+	expected := map[string]interface{}{"s": "v", "i": int64(1), "b": true, "d": time.Second, "k": 2.5}
+	for k, v := range expected {
+		if h.values[k] != v {
+			t.Errorf("%s: expected %v, got %v", k, v, h.values[k])
+		}
+	}
+}
+
+type briefFormatter struct{}
+
+func (f *briefFormatter) Format(buf []byte, e *log.Entry) []byte {
+	buf = append(buf, e.Level.String()...)
+	buf = append(buf, ' ')
+	return append(buf, e.Message...)
+}
+
+func TestLogger_SetFormatter(t *testing.T) {
+	var buf bytes.Buffer
+	l := log.NewLogger(&buf)
+	l.SetFormatter(&briefFormatter{})
+	l.Warn("hello")
+	if buf.String() != "WRN hello\n" {
+		t.Errorf("unexpected: %q", buf.String())
+	}
+}
+
+// sliceFormatter is uncomparable as it holds a slice
+type sliceFormatter struct {
+	parts []string
+}
+
+func (f sliceFormatter) Format(buf []byte, e *log.Entry) []byte {
+	buf = append(buf, strings.Join(f.parts, ",")...)
+	buf = append(buf, ' ')
+	return append(buf, e.Message...)
+}
+
+// sliceEncoder is an uncomparable Encoder
+type sliceEncoder struct {
+	sliceFormatter
+}
+
+func (e sliceEncoder) Encode(buf []byte, entry *log.Entry) []byte {
+	return append(e.Format(buf, entry), '\n')
+}
+
+func TestLogger_SetFormatter_Uncomparable(t *testing.T) {
+	f := sliceFormatter{parts: []string{"x", "y"}}
+	for _, fm := range []log.Formatter{f, sliceEncoder{f}} {
+		var buf1, buf2 bytes.Buffer
+		l := log.NewLogger(&buf1)
+		l.AddOutput(&buf2)
+		l.SetFormatter(fm)
+		l.Info("hello")
+		for _, buf := range []*bytes.Buffer{&buf1, &buf2} {
+			if buf.String() != "x,y hello\n" {
+				t.Errorf("expected %q, got %q", "x,y hello\n", buf.String())
+			}
+		}
+	}
+}
+
+func TestLogger_SetSampler(t *testing.T) {
+	var buf bytes.Buffer
+	l := log.NewLogger(&buf)
+	l.SetFlags(log.Lname)
+	l.SetSampler(log.NewSampler(time.Hour, 2, 3))
+	for i := 0; i < 10; i++ {
+		l.Errorf("retry %d", i)
+	}
+	l.Info("other")
+	// logged: 0, 1, 4, 7
+	expected := "[ERR] retry 0\n[ERR] retry 1\n[ERR] retry 4\n[ERR] retry 7\n[INF] other\n"
+	if buf.String() != expected {
+		t.Fatalf("expected %q, got %q", expected, buf.String())
+	}
+
+	buf.Reset()
+	if err := l.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	expected = "[ERR] Sampler suppressed 6 entries in 1h0m0s: retry %d\n"
+	if buf.String() != expected {
+		t.Errorf("expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestLogger_SetSampler_Origin(t *testing.T) {
+	var buf bytes.Buffer
+	l := log.NewLogger(&buf)
+	l.SetFlags(log.Lname | log.Lshortfile)
+	l.SetSampler(log.NewSampler(20*time.Millisecond, 1, 0))
+	db := l.Derive("db").With("table", "users")
+	for i := 0; i < 5; i++ {
+		db.Error("query failed")
+	}
+	time.Sleep(30 * time.Millisecond)
+	buf.Reset()
+	// the sweep triggered by http reports the storm of db
+	l.Derive("http").With("path", "/x").Error("query failed")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 || lines[0] != "[ERR] [db] table:users  | Sampler suppressed 4 entries in 20ms: query failed" {
+		t.Errorf("unexpected summary: %q", buf.String())
+	}
+}
+
+func TestLogger_Every(t *testing.T) {
+	var buf bytes.Buffer
+	l := log.NewLogger(&buf)
+	for i := 0; i < 5; i++ {
+		l.Every(time.Hour).Info("every")
+		l.Once("k").Infow("once")
+		l.Once("k").Info("once at another call site")
+	}
+	if n := strings.Count(buf.String(), "\n"); n != 3 {
+		t.Errorf("expected 3 lines, got %d: %s", n, buf.String())
+	}
+
+	// loggers created by NewLogger don't share limits at the same call site
+	buf.Reset()
+	for i := 0; i < 2; i++ {
+		log.NewLogger(&buf).Once("k").Info("new logger")
+	}
+	if n := strings.Count(buf.String(), "\n"); n != 2 {
+		t.Errorf("expected 2 lines, got %d: %s", n, buf.String())
+	}
+}
+
+func TestSetLevels(t *testing.T) {
+	var buf bytes.Buffer
+	l := log.NewLogger(&buf)
+	l.SetFlags(log.Lname)
+	sql := l.Derive("db.sql")
+	http := l.Derive("http")
+	if err := log.SetLevels("db=debug,http=warn"); err != nil {
+		t.Fatal(err)
+	}
+	defer log.SetLevels("")
+
+	sql.Debug("query")
+	http.Info("request")
+	http.Warn("slow")
+	expected := "[DEB] [db.sql] query\n[WRN] [http] slow\n"
+	if buf.String() != expected {
+		t.Errorf("expected %q, got %q", expected, buf.String())
+	}
+	if sql.Level() != log.DebugLevel {
+		t.Errorf("expected debug, got %v", sql.Level())
+	}
+
+	if err := log.SetLevels("db=verbose"); err == nil {
+		t.Error("expected error")
+	}
+}
@@ -0,0 +1,183 @@
+package log
+
+import (
+	"fmt"
+	"strings"
+)
+
+type patternVerb int
+
+const (
+	literalVerb patternVerb = iota
+	timeVerb
+	levelVerb
+	loggerVerb
+	callerVerb
+	fileVerb
+	lineVerb
+	functionVerb
+	fieldsVerb
+	messageVerb
+)
+
+var patternVerbs = map[string]patternVerb{
+	"time":   timeVerb,
+	"level":  levelVerb,
+	"logger": loggerVerb,
+	"caller": callerVerb,
+	"file":   fileVerb,
+	"line":   lineVerb,
+	"func":   functionVerb,
+	"fields": fieldsVerb,
+	"msg":    messageVerb,
+}
+
+type patternPart struct {
+	verb patternVerb
+	text string // literal text, time layout, or level style
+}
+
+// PatternFormatter formats entries by a layout pattern, which is compiled once by NewPatternFormatter.
+// Verbs:
+// %time          time encoded by the logger's TimeEncoder, e.g. 2021-10-25 12:00:00.000+0800
+// %time{layout}  time in Go layout, e.g. %time{2006-01-02T15:04:05.000Z07:00}
+// %level         level tag, e.g. INF
+// %level{name}   level name, e.g. info
+// %logger        logger name
+// %caller        file:line, or function:line if file is not available
+// %file, %line, %func
+// %fields        fields as key:value separated by spaces
+// %msg           message
+// %%             percent sign
+// Time, caller, file, line and function are empty if the corresponding flags are not set, e.g. %time requires
+// Ldate or Ltime.
+// Example:
+// f, err := log.NewPatternFormatter("%time{2006-01-02T15:04:05.000Z07:00} %level %logger %caller | %fields | %msg")
+type PatternFormatter struct {
+	parts []patternPart
+}
+
+var _ Formatter = (*PatternFormatter)(nil)
+
+// NewPatternFormatter compiles pattern. It returns an error for unknown verbs or malformed arguments
+func NewPatternFormatter(pattern string) (*PatternFormatter, error) {
+	f := &PatternFormatter{}
+	var literal strings.Builder
+	for i := 0; i < len(pattern); {
+		c := pattern[i]
+		if c != '%' {
+			literal.WriteByte(c)
+			i++
+			continue
+		}
+
+		i++
+		if i < len(pattern) && pattern[i] == '%' {
+			literal.WriteByte('%')
+			i++
+			continue
+		}
+
+		start := i
+		for i < len(pattern) && pattern[i] >= 'a' && pattern[i] <= 'z' {
+			i++
+		}
+		name := pattern[start:i]
+		verb, ok := patternVerbs[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown verb %%%s at %d", name, start-1)
+		}
+
+		var arg string
+		if i < len(pattern) && pattern[i] == '{' {
+			end := strings.IndexByte(pattern[i:], '}')
+			if end < 0 {
+				return nil, fmt.Errorf("missing } of %%%s at %d", name, start-1)
+			}
+			arg = pattern[i+1 : i+end]
+			i += end + 1
+			switch {
+			case verb == timeVerb && arg != "":
+			case verb == levelVerb && arg == "name":
+			default:
+				return nil, fmt.Errorf("invalid argument {%s} of %%%s at %d", arg, name, start-1)
+			}
+		}
+
+		if literal.Len() > 0 {
+			f.parts = append(f.parts, patternPart{verb: literalVerb, text: literal.String()})
+			literal.Reset()
+		}
+		f.parts = append(f.parts, patternPart{verb: verb, text: arg})
+	}
+
+	if literal.Len() > 0 {
+		f.parts = append(f.parts, patternPart{verb: literalVerb, text: literal.String()})
+	}
+	return f, nil
+}
+
+// MustPatternFormatter is like NewPatternFormatter but panics if pattern is invalid
+func MustPatternFormatter(pattern string) *PatternFormatter {
+	f, err := NewPatternFormatter(pattern)
+	if err != nil {
+		panic(err)
+	}
+	return f
+}
+
+func (f *PatternFormatter) Format(buf []byte, e *Entry) []byte {
+	for _, p := range f.parts {
+		switch p.verb {
+		case literalVerb:
+			buf = append(buf, p.text...)
+		case timeVerb:
+			if e.Time.IsZero() {
+				break
+			}
+			if p.text == "" {
+				buf = e.AppendTime(buf)
+			} else {
+				buf = e.Time.AppendFormat(buf, p.text)
+			}
+		case levelVerb:
+			if p.text == "" {
+				buf = append(buf, e.Level.String()...)
+			} else {
+				buf = append(buf, e.Level.name()...)
+			}
+		case loggerVerb:
+			buf = append(buf, e.Name...)
+		case callerVerb:
+			if len(e.File) > 0 {
+				buf = append(buf, e.File...)
+			} else if len(e.Function) > 0 {
+				buf = append(buf, e.Function...)
+			} else {
+				break
+			}
+			buf = append(buf, ':')
+			itoa(&buf, e.Line, -1)
+		case fileVerb:
+			buf = append(buf, e.File...)
+		case lineVerb:
+			if e.Line > 0 {
+				itoa(&buf, e.Line, -1)
+			}
+		case functionVerb:
+			buf = append(buf, e.Function...)
+		case fieldsVerb:
+			for i, fd := range e.Fields {
+				if i > 0 {
+					buf = append(buf, ' ')
+				}
+				buf = append(buf, fd.Key...)
+				buf = append(buf, ':')
+				buf = fd.appendText(buf)
+			}
+		case messageVerb:
+			buf = append(buf, e.Message...)
+		}
+	}
+	return buf
+}
@@ -0,0 +1,27 @@
+package log_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/gopub/log"
+)
+
+func TestPatternFormatter(t *testing.T) {
+	var buf bytes.Buffer
+	l := log.NewLogger(&buf)
+	l.SetFlags(log.Lname | log.Lfunction)
+	l.SetFormatter(log.MustPatternFormatter("%level{name} 100%% [%logger] %func | %fields | %msg"))
+	l.Derive("db").With("id", 1, "ok", true).Info("done")
+
+	expected := "info 100% [db] github.com/gopub/log_test.TestPatternFormatter | id:1 ok:true | done\n"
+	if buf.String() != expected {
+		t.Errorf("expected %q, got %q", expected, buf.String())
+	}
+
+	for _, p := range []string{"%lvl", "%msg{x}", "%time{2006", "%time{}"} {
+		if _, err := log.NewPatternFormatter(p); err == nil {
+			t.Errorf("expected error for %q", p)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package log_test
+
+import (
+	"io/ioutil"
+	"sync"
+	"testing"
+
+	"github.com/gopub/log"
+)
+
+// TestConcurrentConfiguration is meaningful with the race detector: go test -race
+func TestConcurrentConfiguration(t *testing.T) {
+	old := log.Default()
+	defer log.SetDefault(old)
+	level, flags := log.GetLevel(), log.Flags()
+	defer func() {
+		log.SetLevel(level)
+		log.SetFlags(flags)
+	}()
+
+	l := log.NewLogger(ioutil.Discard)
+	log.SetDefault(l)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				log.Info("package-level")
+				l.Debugf("logger %d", j)
+				log.GetLogger("race").Infow("derived", "j", j)
+			}
+		}()
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				log.SetLevel(log.Level(j%3) + log.DebugLevel)
+				log.SetFlags(log.LstdFlags ^ j%2)
+				l.SetLevel(log.InfoLevel)
+				l.SetFlags(log.Lname | log.Ldate)
+				l.SetName("race")
+				l.SetTimeEncoder(log.RFC3339NanoTimeEncoder)
+				log.SetDefault(l)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,162 @@
+package log
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// registry records loggers created by GetLogger and Derive by name, and level overrides by name.
+// Overrides are hierarchical by '.', e.g. override of "db" applies to "db.sql" unless "db.sql" has its own.
+var registry = &levelRegistry{
+	loggers: make(map[string]*Logger),
+}
+
+type levelRegistry struct {
+	mu sync.Mutex
+	// loggers keeps the logger named last for each name, so at most one logger per name is retained
+	loggers   map[string]*Logger
+	overrides atomic.Value // map[string]Level, replaced on write
+}
+
+func (r *levelRegistry) register(name string, l *Logger) {
+	if name == "" {
+		return
+	}
+	r.mu.Lock()
+	r.loggers[name] = l
+	r.mu.Unlock()
+}
+
+// Logger returns the logger named last with name, or nil
+func (r *levelRegistry) Logger(name string) *Logger {
+	r.mu.Lock()
+	l := r.loggers[name]
+	r.mu.Unlock()
+	return l
+}
+
+func (r *levelRegistry) Names() []string {
+	r.mu.Lock()
+	names := make([]string, 0, len(r.loggers))
+	for name := range r.loggers {
+		names = append(names, name)
+	}
+	r.mu.Unlock()
+	sort.Strings(names)
+	return names
+}
+
+func (r *levelRegistry) Overrides() map[string]Level {
+	m, _ := r.overrides.Load().(map[string]Level)
+	return m
+}
+
+// Lookup returns the override of name or its nearest ancestor
+func (r *levelRegistry) Lookup(name string) (Level, bool) {
+	m := r.Overrides()
+	if len(m) == 0 || name == "" {
+		return 0, false
+	}
+	for {
+		if level, ok := m[name]; ok {
+			return level, true
+		}
+		i := strings.LastIndexByte(name, '.')
+		if i < 0 {
+			return 0, false
+		}
+		name = name[:i]
+	}
+}
+
+// update replaces overrides with the result of f applied to a copy of them
+func (r *levelRegistry) update(f func(m map[string]Level)) {
+	r.mu.Lock()
+	old := r.Overrides()
+	m := make(map[string]Level, len(old)+1)
+	for k, v := range old {
+		m[k] = v
+	}
+	f(m)
+	r.overrides.Store(m)
+	r.mu.Unlock()
+}
+
+// SetLoggerLevel overrides the level of loggers named name and their descendants, e.g. "db" applies to "db.sql".
+// It applies to existing loggers as well, and takes precedence over Logger.SetLevel.
+func SetLoggerLevel(name string, level Level) {
+	registry.update(func(m map[string]Level) {
+		m[name] = level
+	})
+}
+
+// ResetLoggerLevel removes the level override of name
+func ResetLoggerLevel(name string) {
+	registry.update(func(m map[string]Level) {
+		delete(m, name)
+	})
+}
+
+// LoggerLevels returns level overrides by logger name
+func LoggerLevels() map[string]Level {
+	m := registry.Overrides()
+	res := make(map[string]Level, len(m))
+	for k, v := range m {
+		res[k] = v
+	}
+	return res
+}
+
+// LoggerNames returns sorted names of loggers created by GetLogger and Derive
+func LoggerNames() []string {
+	return registry.Names()
+}
+
+// SetLevels parses spec like "db=debug,http=warn,*=info" and replaces all level overrides with it.
+// Name * sets the package-level level, see SetLevel. Nothing changes if spec is invalid.
+func SetLevels(spec string) error {
+	levels, err := parseLevels(spec)
+	if err != nil {
+		return err
+	}
+	if level, ok := levels["*"]; ok {
+		SetLevel(level)
+		delete(levels, "*")
+	}
+	registry.update(func(m map[string]Level) {
+		for k := range m {
+			delete(m, k)
+		}
+		for k, v := range levels {
+			m[k] = v
+		}
+	})
+	return nil
+}
+
+func parseLevels(spec string) (map[string]Level, error) {
+	levels := make(map[string]Level)
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		i := strings.IndexByte(item, '=')
+		if i < 0 {
+			return nil, fmt.Errorf("missing = in %q", item)
+		}
+		name := strings.TrimSpace(item[:i])
+		if name == "" {
+			return nil, fmt.Errorf("missing name in %q", item)
+		}
+		level, err := ParseLevel(item[i+1:])
+		if err != nil {
+			return nil, fmt.Errorf("parse %q: %w", item, err)
+		}
+		levels[name] = level
+	}
+	return levels, nil
+}
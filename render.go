@@ -3,33 +3,77 @@ package log
 import (
 	"fmt"
 	"io"
+	"log"
+	"reflect"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
+type output struct {
+	w         io.Writer
+	level     Level
+	encoder   Encoder // nil means the render's encoder
+	colorable bool
+}
+
+func newOutput(w io.Writer, level Level, enc Encoder) *output {
+	return &output{
+		w:         w,
+		level:     level,
+		encoder:   enc,
+		colorable: isColorable(w),
+	}
+}
+
+// encoded is the range of render.buf holding an entry encoded by encoder
+type encoded struct {
+	encoder    Encoder
+	start, end int
+}
+
 type render struct {
-	outputs []io.Writer
+	outputs []*output
+	encoder Encoder
 	mu      sync.Mutex
 	buf     []byte
+	encoded []encoded
+	hooks   []Hook
+	sampler atomic.Value // *Sampler
+	limits  sync.Map     // state of limiters created by Every and Once, keyed by everyKey or onceKey
 }
 
 func newRender(outputs ...io.Writer) *render {
-	return &render{
-		outputs: outputs,
+	r := &render{
+		encoder: TextFormatter{},
 		buf:     make([]byte, 0, 2048), // 2048 bytes should be enough for most Log entry
 	}
+	for _, w := range outputs {
+		r.outputs = append(r.outputs, newOutput(w, AllLevel, nil))
+	}
+	return r
 }
 
-func (r *render) AddOutput(o io.Writer) {
+// SetEncoder sets the encoder of outputs which have no own encoder. nil means the default text encoder
+func (r *render) SetEncoder(enc Encoder) {
+	if enc == nil {
+		enc = TextFormatter{}
+	}
 	r.mu.Lock()
-	r.outputs = append(r.outputs, o)
+	r.encoder = enc
 	r.mu.Unlock()
 }
 
-func (r *render) RemoveOutput(o io.Writer) {
+func (r *render) AddOutput(w io.Writer, level Level, enc Encoder) {
 	r.mu.Lock()
-	for i, v := range r.outputs {
-		if v == o {
+	r.outputs = append(r.outputs, newOutput(w, level, enc))
+	r.mu.Unlock()
+}
+
+func (r *render) RemoveOutput(w io.Writer) {
+	r.mu.Lock()
+	for i, o := range r.outputs {
+		if o.w == w {
 			r.outputs = append(r.outputs[:i], r.outputs[i+1:]...)
 			break
 		}
@@ -37,16 +81,52 @@ func (r *render) RemoveOutput(o io.Writer) {
 	r.mu.Unlock()
 }
 
-func (r *render) Render(e *entry) error {
+func (r *render) SetSampler(s *Sampler) {
+	r.sampler.Store(s)
+}
+
+func (r *render) Sampler() *Sampler {
+	s, _ := r.sampler.Load().(*Sampler)
+	return s
+}
+
+func (r *render) AddHook(h Hook) {
 	r.mu.Lock()
+	r.hooks = append(r.hooks, h)
+	r.mu.Unlock()
+}
+
+// fire invokes hooks without holding r.mu, so that slow hooks don't block rendering
+func (r *render) fire(e *Entry) {
+	r.mu.Lock()
+	hooks := r.hooks
+	r.mu.Unlock()
+	for _, h := range hooks {
+		if !hasLevel(h.Levels(), e.Level) {
+			continue
+		}
+		if err := h.Fire(e); err != nil {
+			log.Printf("Fire hook: %v\n", err)
+		}
+	}
+}
+
+func (r *render) Render(e *Entry) error {
+	r.fire(e)
+	r.mu.Lock()
+	// unlock by defer, so that a panicking encoder or writer doesn't leave r locked
+	defer r.mu.Unlock()
 
 	r.buf = r.buf[0:0]
-	renderEntry(&r.buf, e)
+	r.encoded = r.encoded[0:0]
 
 	// flush buffer to writer
 	var err error
 	for _, o := range r.outputs {
-		_, oErr := o.Write(r.buf)
+		if e.Level < o.level {
+			continue
+		}
+		_, oErr := o.w.Write(r.encode(o, e))
 		if oErr != nil {
 			if err == nil {
 				err = oErr
@@ -55,31 +135,118 @@ func (r *render) Render(e *entry) error {
 			}
 		}
 	}
-	r.mu.Unlock()
+	return err
+}
+
+// encode encodes e for o. e is encoded at most once per encoder in a Render call, outputs sharing the same
+// encoder share the bytes. Encoders of uncomparable types, e.g. struct values holding a slice, are not shared
+// as comparing them panics
+func (r *render) encode(o *output, e *Entry) []byte {
+	enc := o.encoder
+	if enc == nil {
+		enc = r.encoder
+	}
+	if oe, ok := enc.(outputEncoder); ok {
+		enc = oe.forOutput(o.colorable)
+	}
+	comparable := reflect.TypeOf(enc).Comparable()
+	if comparable {
+		for _, v := range r.encoded {
+			if v.encoder == enc {
+				return r.buf[v.start:v.end]
+			}
+		}
+	}
+	start := len(r.buf)
+	r.buf = enc.Encode(r.buf, e)
+	if comparable {
+		r.encoded = append(r.encoded, encoded{encoder: enc, start: start, end: len(r.buf)})
+	}
+	return r.buf[start:]
+}
+
+// Sync flushes all outputs and returns the first error
+func (r *render) Sync() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var err error
+	for _, o := range r.outputs {
+		if oErr := flushWriter(o.w); oErr != nil && err == nil {
+			err = oErr
+		}
+	}
+	return err
+}
+
+// Close flushes and closes all outputs except os.Stdout and os.Stderr, and returns the first error
+func (r *render) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var err error
+	for _, o := range r.outputs {
+		if isStdStream(o.w) {
+			continue
+		}
+		c, ok := o.w.(io.Closer)
+		if !ok {
+			if oErr := flushWriter(o.w); oErr != nil && err == nil {
+				err = oErr
+			}
+			continue
+		}
+		// AsyncWriter.Close flushes by itself
+		if _, ok := c.(*AsyncWriter); !ok {
+			if oErr := flushWriter(o.w); oErr != nil && err == nil {
+				err = oErr
+			}
+		}
+		if oErr := c.Close(); oErr != nil && err == nil {
+			err = oErr
+		}
+	}
 	return err
 }
 
 // RenderString is only called by Log.Panic[f], it's ok to use local buffer
-func (r *render) RenderString(e *entry) string {
+func (r *render) RenderString(e *Entry) string {
 	r.mu.Lock()
+	defer r.mu.Unlock()
 	r.buf = r.buf[0:0]
-	renderEntry(&r.buf, e)
-	str := string(r.buf)
-	r.mu.Unlock()
-	return str
+	renderEntry(&r.buf, e, false)
+	return string(r.buf)
 }
 
-func renderEntry(buf *[]byte, e *entry) {
-	writeTime(buf, e.Time, e.Flags)
+// renderEntry writes the bracketed plain-text line. If colored, level tag, logger name and field keys are
+// wrapped in ANSI color escapes
+func renderEntry(buf *[]byte, e *Entry, colored bool) {
+	n := len(*buf)
+	*buf = e.AppendTime(*buf)
+	if len(*buf) > n {
+		*buf = append(*buf, ' ')
+	}
 
+	if colored {
+		*buf = append(*buf, levelColor(e.Level)...)
+	}
 	*buf = append(*buf, '[')
 	*buf = append(*buf, e.Level.String()...)
-	*buf = append(*buf, ']', ' ')
+	*buf = append(*buf, ']')
+	if colored {
+		*buf = append(*buf, colorReset...)
+	}
+	*buf = append(*buf, ' ')
 
-	if len(e.Name) > 0 {
+	if e.Flags&Lname != 0 && len(e.Name) > 0 {
+		if colored {
+			*buf = append(*buf, colorName...)
+		}
 		*buf = append(*buf, '[')
 		*buf = append(*buf, e.Name...)
-		*buf = append(*buf, ']', ' ')
+		*buf = append(*buf, ']')
+		if colored {
+			*buf = append(*buf, colorReset...)
+		}
+		*buf = append(*buf, ' ')
 	}
 
 	if len(e.File) > 0 {
@@ -102,9 +269,15 @@ func renderEntry(buf *[]byte, e *entry) {
 	}
 
 	for _, f := range e.Fields {
-		*buf = append(*buf, f.Key...)
+		if colored {
+			*buf = append(*buf, colorKey...)
+			*buf = append(*buf, f.Key...)
+			*buf = append(*buf, colorReset...)
+		} else {
+			*buf = append(*buf, f.Key...)
+		}
 		*buf = append(*buf, ':')
-		*buf = append(*buf, fmt.Sprintf("%+v", f.Value)...)
+		*buf = f.appendText(*buf)
 		*buf = append(*buf, ' ')
 	}
 
@@ -121,7 +294,7 @@ func renderEntry(buf *[]byte, e *entry) {
 }
 
 func writeTime(buf *[]byte, t time.Time, flags int) {
-	if t.Unix() == 0 || flags&(Ldate|Ltime|Lmillisecond|Lmicroseconds) == 0 {
+	if t.IsZero() || flags&(Ldate|Ltime|Lmillisecond|Lmicroseconds) == 0 {
 		return
 	}
 
@@ -132,10 +305,12 @@ func writeTime(buf *[]byte, t time.Time, flags int) {
 		itoa(buf, int(month), 2)
 		*buf = append(*buf, '-')
 		itoa(buf, day, 2)
-		*buf = append(*buf, ' ')
 	}
 
 	if flags&(Ltime|Lmillisecond|Lmicroseconds) != 0 {
+		if flags&Ldate != 0 {
+			*buf = append(*buf, ' ')
+		}
 		hour, min, sec := t.Clock()
 		itoa(buf, hour, 2)
 		*buf = append(*buf, ':')
@@ -149,12 +324,17 @@ func writeTime(buf *[]byte, t time.Time, flags int) {
 			*buf = append(*buf, '.')
 			itoa(buf, t.Nanosecond()/1e6, 3)
 		}
+		// e.g. offset of UTC+0530 is 19800 seconds, which is 5 hours and 30 minutes
 		_, offset := t.Zone()
-		// e.g. UTC+0800, offset is 28800 seconds, +0800 = offset/3600*100 = offset/36
-		offset = offset / 36
-		*buf = append(*buf, '+')
-		itoa(buf, offset, 4)
-		*buf = append(*buf, ' ')
+		if offset < 0 {
+			*buf = append(*buf, '-')
+			offset = -offset
+		} else {
+			*buf = append(*buf, '+')
+		}
+		offset /= 60
+		itoa(buf, offset/60, 2)
+		itoa(buf, offset%60, 2)
 	}
 }
 
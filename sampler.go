@@ -0,0 +1,118 @@
+package log
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+type sampleKey struct {
+	level    Level
+	name     string
+	template string
+}
+
+type sampleCounter struct {
+	start      time.Time
+	n          int
+	suppressed int
+	logger     *Logger // logger of the first entry in the interval, whose name and fields the summary carries
+}
+
+// Sampler limits repeated entries. Entries are keyed by level, logger name and message template (format of Logf,
+// or message). In each interval, the first entries of a key are logged, then every thereafter-th entry, others are
+// suppressed. A summary of suppressed entries is logged when the interval of a key ends, or on Logger.Sync, with
+// the name and fields of the logger which logged the first entry of the interval.
+// Example:
+// log.Default().SetSampler(log.NewSampler(time.Second, 10, 100))
+type Sampler struct {
+	interval   time.Duration
+	first      int
+	thereafter int
+
+	mu        sync.Mutex
+	counters  map[sampleKey]*sampleCounter
+	lastSweep time.Time
+}
+
+// NewSampler creates a Sampler which logs the first entries per key in each interval, then every thereafter-th.
+// thereafter <= 0 means suppressing all after the first entries.
+func NewSampler(interval time.Duration, first, thereafter int) *Sampler {
+	return &Sampler{
+		interval:   interval,
+		first:      first,
+		thereafter: thereafter,
+		counters:   make(map[sampleKey]*sampleCounter),
+		lastSweep:  time.Now(),
+	}
+}
+
+// sampleSummary describes entries of a key suppressed in an ended interval
+type sampleSummary struct {
+	key        sampleKey
+	suppressed int
+	logger     *Logger
+}
+
+func (c *sampleCounter) summary(key sampleKey) sampleSummary {
+	return sampleSummary{key: key, suppressed: c.suppressed, logger: c.logger}
+}
+
+func (s sampleSummary) message(interval time.Duration) string {
+	return fmt.Sprintf("Sampler suppressed %d entries in %v: %s", s.suppressed, interval, s.key.template)
+}
+
+// sample reports whether an entry of l should be logged, and returns summaries of ended intervals
+func (s *Sampler) sample(l *Logger, level Level, template string) (bool, []sampleSummary) {
+	now := time.Now()
+	key := sampleKey{level: level, name: l.Name(), template: template}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var summaries []sampleSummary
+	c := s.counters[key]
+	if c == nil {
+		c = &sampleCounter{start: now, logger: l}
+		s.counters[key] = c
+	} else if now.Sub(c.start) >= s.interval {
+		if c.suppressed > 0 {
+			summaries = append(summaries, c.summary(key))
+		}
+		*c = sampleCounter{start: now, logger: l}
+	}
+
+	// drop counters of keys which didn't recur, so that the map doesn't grow without bound
+	if now.Sub(s.lastSweep) >= s.interval {
+		s.lastSweep = now
+		for k, v := range s.counters {
+			if now.Sub(v.start) < s.interval {
+				continue
+			}
+			if v.suppressed > 0 {
+				summaries = append(summaries, v.summary(k))
+			}
+			delete(s.counters, k)
+		}
+	}
+
+	c.n++
+	if c.n <= s.first || (s.thereafter > 0 && (c.n-s.first)%s.thereafter == 0) {
+		return true, summaries
+	}
+	c.suppressed++
+	return false, summaries
+}
+
+// flush returns summaries of suppressed entries and resets counters
+func (s *Sampler) flush() []sampleSummary {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var summaries []sampleSummary
+	for k, v := range s.counters {
+		if v.suppressed > 0 {
+			summaries = append(summaries, v.summary(k))
+		}
+		v.suppressed = 0
+	}
+	return summaries
+}
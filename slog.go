@@ -0,0 +1,128 @@
+//go:build go1.21
+// +build go1.21
+
+package log
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+)
+
+// slogHandler implements slog.Handler by logging records with a Logger
+type slogHandler struct {
+	l      *Logger
+	prefix string // keys of groups opened by WithGroup, e.g. "request."
+}
+
+// SlogHandler returns a slog.Handler which writes records into outputs of l with l's name, level, flags and fields.
+// Attrs become fields, groups become prefixes of field keys separated by '.', and the caller is resolved from
+// the record's PC.
+// Example:
+// slog.SetDefault(slog.New(log.Default().SlogHandler()))
+func (l *Logger) SlogHandler() slog.Handler {
+	return &slogHandler{l: l}
+}
+
+func (h *slogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.l.Level() <= levelFromSlog(level)
+}
+
+func (h *slogHandler) Handle(ctx context.Context, r slog.Record) error {
+	l := h.l
+	level := levelFromSlog(r.Level)
+	if l.Level() > level {
+		return nil
+	}
+	if l.limiter != nil && r.PC != 0 && !l.limiter.allow(&l.render.limits, r.PC) {
+		return nil
+	}
+	if !l.sample(level, r.Message) {
+		return nil
+	}
+
+	fields := make([]*Field, 0, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		fields = appendSlogAttr(fields, h.prefix, a)
+		return true
+	})
+	fields = append(fields, l.contextFields(ctx)...)
+
+	e := newEntryAt(l.Flags(), level, l.Name(), l.entryFields(fields), r.Message, r.Time)
+	e.timeEncoder = l.TimeEncoder()
+	if r.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		e.setCaller(frame.Function, frame.File, frame.Line)
+	}
+	return l.render.Render(e)
+}
+
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	var fields []*Field
+	for _, a := range attrs {
+		fields = appendSlogAttr(fields, h.prefix, a)
+	}
+	if len(fields) == 0 {
+		return h
+	}
+	return &slogHandler{l: h.l.WithFields(fields), prefix: h.prefix}
+}
+
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &slogHandler{l: h.l, prefix: h.prefix + name + "."}
+}
+
+// levelFromSlog maps slog levels, which may lie between the predefined ones, to the nearest lower Level
+func levelFromSlog(level slog.Level) Level {
+	switch {
+	case level < slog.LevelDebug:
+		return TraceLevel
+	case level < slog.LevelInfo:
+		return DebugLevel
+	case level < slog.LevelWarn:
+		return InfoLevel
+	case level < slog.LevelError:
+		return WarnLevel
+	default:
+		return ErrorLevel
+	}
+}
+
+// appendSlogAttr appends a as fields to fields. Groups are flattened, empty attrs and empty groups are ignored
+func appendSlogAttr(fields []*Field, prefix string, a slog.Attr) []*Field {
+	v := a.Value.Resolve()
+	if a.Key == "" && v.Kind() == slog.KindAny && v.Any() == nil {
+		return fields
+	}
+	key := prefix + a.Key
+	switch v.Kind() {
+	case slog.KindGroup:
+		attrs := v.Group()
+		if a.Key != "" {
+			prefix = key + "."
+		}
+		for _, ga := range attrs {
+			fields = appendSlogAttr(fields, prefix, ga)
+		}
+		return fields
+	case slog.KindString:
+		return append(fields, String(key, v.String()))
+	case slog.KindInt64:
+		return append(fields, Int64(key, v.Int64()))
+	case slog.KindUint64:
+		return append(fields, Uint64(key, v.Uint64()))
+	case slog.KindFloat64:
+		return append(fields, Float64(key, v.Float64()))
+	case slog.KindBool:
+		return append(fields, Bool(key, v.Bool()))
+	case slog.KindDuration:
+		return append(fields, Duration(key, v.Duration()))
+	case slog.KindTime:
+		return append(fields, Time(key, v.Time()))
+	default:
+		return append(fields, Any(key, v.Any()))
+	}
+}
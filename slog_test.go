@@ -0,0 +1,54 @@
+//go:build go1.21
+// +build go1.21
+
+package log_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"runtime"
+	"strconv"
+	"testing"
+
+	"github.com/gopub/log"
+)
+
+func TestLogger_SlogHandler(t *testing.T) {
+	var buf bytes.Buffer
+	l := log.NewLogger(&buf)
+	l.SetEncoder(log.NewLogfmtEncoder())
+	l.SetFlags(log.Lname)
+	l.SetLevel(log.InfoLevel)
+	sl := slog.New(l.With("service", "api").SlogHandler())
+
+	sl.Debug("hidden")
+	sl.With("user", 1).WithGroup("req").With("method", "GET").Info("handled", "status", 200,
+		slog.Group("time", "total", "2ms"), slog.Group("empty"))
+	sl.Log(context.Background(), slog.LevelWarn+1, "slow", slog.Group("", "inline", true))
+	sl.Error("failed", "ok", false)
+
+	expected := "level=info msg=handled service=api user=1 req.method=GET req.status=200 req.time.total=2ms\n" +
+		"level=warn msg=slow service=api inline=true\n" +
+		"level=error msg=failed service=api ok=false\n"
+	if buf.String() != expected {
+		t.Errorf("expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestLogger_SlogHandlerCaller(t *testing.T) {
+	var buf bytes.Buffer
+	l := log.NewLogger(&buf)
+	l.SetEncoder(log.NewJSONEncoder())
+	l.SetFlags(log.Llongfile | log.Lfunction)
+	sl := slog.New(l.SlogHandler())
+
+	_, file, line, _ := runtime.Caller(0)
+	sl.Info("here")
+
+	expected := `{"level":"info","caller":"` + log.RelativePath(file) + ":" + strconv.Itoa(line+1) +
+		`","function":"TestLogger_SlogHandlerCaller","msg":"here"}` + "\n"
+	if buf.String() != expected {
+		t.Errorf("expected %q, got %q", expected, buf.String())
+	}
+}
@@ -0,0 +1,103 @@
+package log_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gopub/log"
+)
+
+// flushWriter records calls of Flush and Close
+type flushWriter struct {
+	bytes.Buffer
+	flushes int
+	closed  bool
+}
+
+func (w *flushWriter) Flush() error {
+	w.flushes++
+	return nil
+}
+
+func (w *flushWriter) Close() error {
+	w.closed = true
+	return nil
+}
+
+func TestLogger_Sync(t *testing.T) {
+	w := &flushWriter{}
+	l := log.NewLogger(w)
+	l.Info("hello")
+	if err := l.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	if w.flushes != 1 || w.closed {
+		t.Errorf("expected 1 flush without close, got %d flushes, closed %t", w.flushes, w.closed)
+	}
+}
+
+func TestLogger_Close(t *testing.T) {
+	w := &flushWriter{}
+	l := log.NewLogger(w)
+	l.AddOutput(os.Stdout)
+	l.AddOutput(log.NewAsyncWriter(os.Stderr, 16, log.Block))
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if w.flushes != 1 || !w.closed {
+		t.Errorf("expected flush and close, got %d flushes, closed %t", w.flushes, w.closed)
+	}
+	for _, f := range []*os.File{os.Stdout, os.Stderr} {
+		if _, err := f.Stat(); err != nil {
+			t.Errorf("%s is closed: %v", f.Name(), err)
+		}
+	}
+}
+
+func TestLogger_FatalSync(t *testing.T) {
+	w := &flushWriter{}
+	l := log.NewLogger(w)
+	flushes := -1
+	l.SetExitFunc(func(int) {
+		flushes = w.flushes
+	})
+	l.Fatal("fatal")
+	if flushes != 1 {
+		t.Errorf("expected outputs flushed before exit, got %d flushes", flushes)
+	}
+}
+
+func TestFileWriter_Sync(t *testing.T) {
+	dir, err := ioutil.TempDir("", "log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	fw, err := log.NewFileWriter(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = fw.Write([]byte("hello\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err = fw.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	names, err := filepath.Glob(filepath.Join(dir, "*"))
+	if err != nil || len(names) != 1 {
+		t.Fatalf("expected 1 file, got %v, %v", names, err)
+	}
+	data, err := ioutil.ReadFile(names[0])
+	if err != nil || string(data) != "hello\n" {
+		t.Errorf("expected %q, got %q, %v", "hello\n", data, err)
+	}
+	if err = fw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err = fw.Sync(); err == nil {
+		t.Error("expected error after close")
+	}
+}
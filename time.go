@@ -0,0 +1,77 @@
+package log
+
+import (
+	"reflect"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// TimeEncoder appends t to buf and returns the extended buffer. flags are the logger's flags
+type TimeEncoder func(buf []byte, t time.Time, flags int) []byte
+
+// DefaultTimeEncoder writes time in layout decided by flags, e.g. 2021-10-25 12:00:00.000+0800
+func DefaultTimeEncoder(buf []byte, t time.Time, flags int) []byte {
+	writeTime(&buf, t, flags)
+	return buf
+}
+
+// RFC3339NanoTimeEncoder writes time in RFC3339 with nanoseconds, e.g. 2021-10-25T12:00:00.123456789+08:00
+func RFC3339NanoTimeEncoder(buf []byte, t time.Time, flags int) []byte {
+	return t.AppendFormat(buf, time.RFC3339Nano)
+}
+
+// EpochTimeEncoder writes seconds since Unix epoch, e.g. 1635134400
+func EpochTimeEncoder(buf []byte, t time.Time, flags int) []byte {
+	return strconv.AppendInt(buf, t.Unix(), 10)
+}
+
+// EpochMillisTimeEncoder writes milliseconds since Unix epoch, e.g. 1635134400123
+func EpochMillisTimeEncoder(buf []byte, t time.Time, flags int) []byte {
+	return strconv.AppendInt(buf, t.UnixNano()/int64(time.Millisecond), 10)
+}
+
+// LayoutTimeEncoder returns a TimeEncoder writing time in Go layout, e.g. time.Kitchen
+func LayoutTimeEncoder(layout string) TimeEncoder {
+	return func(buf []byte, t time.Time, flags int) []byte {
+		return t.AppendFormat(buf, layout)
+	}
+}
+
+// numericTimeEncoders are code pointers of time encoders writing numbers, as functions are not comparable
+var numericTimeEncoders = []uintptr{
+	reflect.ValueOf(EpochTimeEncoder).Pointer(),
+	reflect.ValueOf(EpochMillisTimeEncoder).Pointer(),
+}
+
+// isNumericTimeEncoder reports whether enc is EpochTimeEncoder or EpochMillisTimeEncoder
+func isNumericTimeEncoder(enc TimeEncoder) bool {
+	if enc == nil {
+		return false
+	}
+	p := reflect.ValueOf(enc).Pointer()
+	for _, np := range numericTimeEncoders {
+		if p == np {
+			return true
+		}
+	}
+	return false
+}
+
+var _timeEncoder atomic.Value // TimeEncoder
+
+// SetTimeEncoder sets the time encoding of loggers without own time encoder. nil means DefaultTimeEncoder.
+// Time is written only if flags contain any of Ldate, Ltime, Lmillisecond and Lmicroseconds.
+func SetTimeEncoder(enc TimeEncoder) {
+	if enc == nil {
+		enc = DefaultTimeEncoder
+	}
+	_timeEncoder.Store(enc)
+}
+
+func timeEncoder() TimeEncoder {
+	if enc, ok := _timeEncoder.Load().(TimeEncoder); ok {
+		return enc
+	}
+	return DefaultTimeEncoder
+}
@@ -0,0 +1,27 @@
+package log_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gopub/log"
+)
+
+func TestDefaultTimeEncoder(t *testing.T) {
+	tests := []struct {
+		offset   int
+		expected string
+	}{
+		{8 * 3600, "2021-10-25 12:00:00.123+0800"},
+		{5*3600 + 1800, "2021-10-25 12:00:00.123+0530"},
+		{-(3*3600 + 1800), "2021-10-25 12:00:00.123-0330"},
+		{0, "2021-10-25 12:00:00.123+0000"},
+	}
+	for _, test := range tests {
+		tm := time.Date(2021, 10, 25, 12, 0, 0, 123e6, time.FixedZone("", test.offset))
+		s := string(log.DefaultTimeEncoder(nil, tm, log.Ldate|log.Lmillisecond))
+		if s != test.expected {
+			t.Errorf("expected %s, got %s", test.expected, s)
+		}
+	}
+}
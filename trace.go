@@ -0,0 +1,122 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// SpanContext identifies the span being executed. It can be implemented by an adapter of
+// OpenTelemetry's trace.SpanContext, e.g. with TraceID().String() and SpanID().String()
+type SpanContext interface {
+	TraceID() string
+	SpanID() string
+	IsValid() bool
+}
+
+// SpanContextFunc returns the span context carried by ctx, or nil
+type SpanContextFunc func(ctx context.Context) SpanContext
+
+// TraceExtractor returns a ContextExtractor which adds fields trace_id and span_id of the valid span context
+// returned by fn.
+// Example:
+// log.RegisterContextExtractor(log.TraceExtractor(func(ctx context.Context) log.SpanContext { return otelSpan{trace.SpanContextFromContext(ctx)} }))
+func TraceExtractor(fn SpanContextFunc) ContextExtractor {
+	return func(ctx context.Context) []*Field {
+		sc := fn(ctx)
+		if sc == nil || !sc.IsValid() {
+			return nil
+		}
+		return []*Field{String("trace_id", sc.TraceID()), String("span_id", sc.SpanID())}
+	}
+}
+
+// ContextWithSpan returns a copy of ctx carrying sc. Fields of sc are added to entries logged by *Context methods
+func ContextWithSpan(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, spanKey, sc)
+}
+
+// SpanFromContext returns the span context stored by ContextWithSpan, or nil if there is none or ctx is nil
+func SpanFromContext(ctx context.Context) SpanContext {
+	if ctx == nil {
+		return nil
+	}
+	sc, _ := ctx.Value(spanKey).(SpanContext)
+	return sc
+}
+
+func init() {
+	RegisterContextExtractor(TraceExtractor(SpanFromContext))
+}
+
+// traceParent is the span context carried by W3C traceparent header
+type traceParent struct {
+	traceID string
+	spanID  string
+}
+
+func (t *traceParent) TraceID() string {
+	return t.traceID
+}
+
+func (t *traceParent) SpanID() string {
+	return t.spanID
+}
+
+func (t *traceParent) IsValid() bool {
+	return !isZeroHex(t.traceID) && !isZeroHex(t.spanID)
+}
+
+// ParseTraceparent parses W3C traceparent header, e.g. 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01
+func ParseTraceparent(s string) (SpanContext, error) {
+	parts := strings.Split(strings.TrimSpace(s), "-")
+	if len(parts) < 4 {
+		return nil, fmt.Errorf("invalid traceparent %q", s)
+	}
+	version := parts[0]
+	if len(version) != 2 || !isLowerHex(version) || version == "ff" {
+		return nil, fmt.Errorf("invalid version %q", version)
+	}
+	// version 00 has exactly 4 parts, later versions may append parts
+	if version == "00" && len(parts) != 4 {
+		return nil, fmt.Errorf("invalid traceparent %q", s)
+	}
+	t := &traceParent{traceID: parts[1], spanID: parts[2]}
+	if len(t.traceID) != 32 || !isLowerHex(t.traceID) || isZeroHex(t.traceID) {
+		return nil, fmt.Errorf("invalid trace id %q", t.traceID)
+	}
+	if len(t.spanID) != 16 || !isLowerHex(t.spanID) || isZeroHex(t.spanID) {
+		return nil, fmt.Errorf("invalid span id %q", t.spanID)
+	}
+	// flags are validated but not kept, as sampling doesn't affect logging
+	if flags := parts[3]; len(flags) != 2 || !isLowerHex(flags) {
+		return nil, fmt.Errorf("invalid flags %q", flags)
+	}
+	return t, nil
+}
+
+// ContextWithTraceparent returns a copy of ctx carrying the span context parsed from W3C traceparent header.
+// ctx is returned if header is invalid.
+// Example:
+// ctx := log.ContextWithTraceparent(req.Context(), req.Header.Get("traceparent"))
+func ContextWithTraceparent(ctx context.Context, header string) context.Context {
+	sc, err := ParseTraceparent(header)
+	if err != nil {
+		return ctx
+	}
+	return ContextWithSpan(ctx, sc)
+}
+
+func isLowerHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func isZeroHex(s string) bool {
+	return strings.Trim(s, "0") == ""
+}
@@ -0,0 +1,59 @@
+package log_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/gopub/log"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	sc, err := log.ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sc.TraceID() != "4bf92f3577b34da6a3ce929d0e0e4736" || sc.SpanID() != "00f067aa0ba902b7" || !sc.IsValid() {
+		t.Errorf("got %s %s", sc.TraceID(), sc.SpanID())
+	}
+
+	for _, s := range []string{
+		"",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-0g",
+	} {
+		if _, err := log.ParseTraceparent(s); err == nil {
+			t.Errorf("expected error for %q", s)
+		}
+	}
+}
+
+func TestTraceFields(t *testing.T) {
+	var buf bytes.Buffer
+	l := log.NewLogger(&buf)
+	l.SetFlags(log.Lname)
+	ctx := log.ContextWithTraceparent(context.Background(), "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	l.InfoContext(ctx, "text")
+	l.SetEncoder(log.NewJSONEncoder())
+	l.InfoContext(ctx, "json")
+	l.InfoContext(context.Background(), "no trace")
+
+	expected := "[INF] trace_id:4bf92f3577b34da6a3ce929d0e0e4736 span_id:00f067aa0ba902b7  | text\n" +
+		`{"level":"info","msg":"json","trace_id":"4bf92f3577b34da6a3ce929d0e0e4736","span_id":"00f067aa0ba902b7"}` + "\n" +
+		`{"level":"info","msg":"no trace"}` + "\n"
+	if buf.String() != expected {
+		t.Errorf("expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestSpanFromContext_Nil(t *testing.T) {
+	var ctx context.Context
+	if sc := log.SpanFromContext(ctx); sc != nil {
+		t.Errorf("expected nil, got %v", sc)
+	}
+}